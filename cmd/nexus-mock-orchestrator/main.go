@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:50505", "监听地址 (默认与 local 环境一致)")
+	scenarioFile := flag.String("scenario", "", "场景文件路径 (JSON，可选)")
+	showHelp := flag.Bool("h", false, "显示帮助信息")
+	flag.Parse()
+
+	if *showHelp {
+		printHelp()
+		return
+	}
+
+	sc, err := loadScenario(*scenarioFile)
+	if err != nil {
+		log.Fatalf("❌ 加载场景失败: %v", err)
+	}
+
+	server := NewServer(sc)
+	log.Printf("🚀 模拟编排服务已启动: http://%s/v3", *listen)
+	log.Printf("   任务池: %v, 每页: %d, 429突发: %+v, 404间隔: %d, 提交404间隔: %d, 慢响应: %+v, 校验签名: %v",
+		sc.Programs, sc.PageSize, sc.RateLimit, sc.NotFoundEvery, sc.SubmitNotFound, sc.Slow, sc.VerifySignatures)
+	log.Printf("   提交记录: http://%s/debug/submissions", *listen)
+	if err := http.ListenAndServe(*listen, server.Handler()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printHelp() {
+	fmt.Println("Nexus 模拟编排服务 (离线开发/测试)")
+	fmt.Println("")
+	fmt.Println("用法:")
+	fmt.Println("  ./nexus-mock-orchestrator [-listen 地址] [-scenario 场景文件]")
+	fmt.Println("  ./nexus-prover -c config.json -env local   # 客户端连接模拟服务")
+	fmt.Println("")
+	fmt.Println("接口:")
+	fmt.Println("  GET  /v3/tasks            # 已分配任务（按 page_size 分页）")
+	fmt.Println("  POST /v3/tasks            # 分配新任务")
+	fmt.Println("  POST /v3/tasks/submit     # 提交证明")
+	fmt.Println("  POST /v3/users            # 注册用户")
+	fmt.Println("  GET  /v3/users/{钱包地址}  # 查询用户节点")
+	fmt.Println("  POST /v3/nodes            # 注册节点")
+	fmt.Println("  GET  /debug/submissions   # 查看全部提交记录")
+	fmt.Println("  GET  /debug/state         # 查看任务池状态")
+	fmt.Println("")
+	fmt.Println("场景文件格式:")
+	fmt.Println("  {")
+	fmt.Println("    \"programs\": {\"fib_input_initial\": 100, \"fib_input\": 50},  # 每个程序的任务池")
+	fmt.Println("    \"page_size\": 2,                                         # 已分配任务每页数量")
+	fmt.Println("    \"rate_limit\": {\"every\": 10, \"burst\": 3, \"retry_after\": 5}, # 每10次请求后连续3次429")
+	fmt.Println("    \"not_found_every\": 5,                                   # 每5次获取新任务返回一次404")
+	fmt.Println("    \"submit_not_found\": 4,                                  # 每4次提交返回一次 Task not found")
+	fmt.Println("    \"slow\": {\"min_ms\": 100, \"max_ms\": 2000},                # 响应延迟区间")
+	fmt.Println("    \"verify_signatures\": true                               # 校验 proof_hash 和签名")
+	fmt.Println("  }")
+}
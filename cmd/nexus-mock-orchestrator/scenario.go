@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Scenario 模拟场景配置
+type Scenario struct {
+	Programs         map[string]int `json:"programs"`          // 每个程序ID的任务池大小
+	PageSize         int            `json:"page_size"`         // 已分配任务每页数量
+	RateLimit        RateLimitBurst `json:"rate_limit"`        // 429 突发
+	NotFoundEvery    int            `json:"not_found_every"`   // 每N次获取新任务返回一次404，0 表示不启用
+	SubmitNotFound   int            `json:"submit_not_found"`  // 每N次提交返回一次 Task not found，0 表示不启用
+	Slow             SlowResponse   `json:"slow"`              // 慢响应
+	VerifySignatures bool           `json:"verify_signatures"` // 校验提交的 proof_hash 和签名
+}
+
+// RateLimitBurst 每 Every 次请求之后连续 Burst 次请求返回429
+type RateLimitBurst struct {
+	Every      int `json:"every"`
+	Burst      int `json:"burst"`
+	RetryAfter int `json:"retry_after"` // Retry-After 秒数，0 表示不返回该头
+}
+
+// SlowResponse 响应延迟区间（毫秒）
+type SlowResponse struct {
+	MinMs int `json:"min_ms"`
+	MaxMs int `json:"max_ms"`
+}
+
+// defaultScenario 默认场景：两个程序各100个任务，不注入故障
+func defaultScenario() *Scenario {
+	return &Scenario{
+		Programs: map[string]int{
+			"fib_input_initial": 100,
+			"fib_input":         100,
+		},
+		PageSize:         2,
+		VerifySignatures: true,
+	}
+}
+
+// loadScenario 读取场景文件，未填写的字段使用默认值。
+// programs 整体替换默认的任务池，不与默认程序合并
+func loadScenario(path string) (*Scenario, error) {
+	if path == "" {
+		return defaultScenario(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sc := &Scenario{VerifySignatures: true}
+	if err := json.Unmarshal(data, sc); err != nil {
+		return nil, fmt.Errorf("解析场景文件失败: %v", err)
+	}
+	if sc.Programs == nil {
+		sc.Programs = defaultScenario().Programs
+	}
+	if sc.PageSize <= 0 {
+		sc.PageSize = 2
+	}
+	if sc.Slow.MaxMs < sc.Slow.MinMs {
+		sc.Slow.MaxMs = sc.Slow.MinMs
+	}
+	return sc, nil
+}
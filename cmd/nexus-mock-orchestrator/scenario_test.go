@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLoadScenario 测试场景文件的默认值，programs 不与默认任务池合并
+func TestLoadScenario(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *Scenario
+		wantErr bool
+	}{
+		{
+			name:    "空场景使用默认值",
+			content: `{}`,
+			want:    defaultScenario(),
+		},
+		{
+			name:    "programs整体替换",
+			content: `{"programs": {"fib_input": 5}}`,
+			want: &Scenario{
+				Programs:         map[string]int{"fib_input": 5},
+				PageSize:         2,
+				VerifySignatures: true,
+			},
+		},
+		{
+			name:    "空programs不使用默认任务池",
+			content: `{"programs": {}, "verify_signatures": false}`,
+			want:    &Scenario{Programs: map[string]int{}, PageSize: 2},
+		},
+		{
+			name:    "修正非法字段",
+			content: `{"page_size": -1, "slow": {"min_ms": 50, "max_ms": 10}}`,
+			want: &Scenario{
+				Programs:         defaultScenario().Programs,
+				PageSize:         2,
+				Slow:             SlowResponse{MinMs: 50, MaxMs: 50},
+				VerifySignatures: true,
+			},
+		},
+		{
+			name:    "非法JSON",
+			content: `{"programs": [1]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadScenario(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误 = %v, 期望出错 %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("场景 = %+v, 期望 %+v", got, tt.want)
+			}
+		})
+	}
+
+	if sc, err := loadScenario(""); err != nil || !reflect.DeepEqual(sc, defaultScenario()) {
+		t.Errorf("未指定场景文件时应使用默认场景: %+v, %v", sc, err)
+	}
+	if _, err := loadScenario(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("场景文件不存在时应返回错误")
+	}
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "nexus-prover/proto"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// mockTask 模拟任务
+type mockTask struct {
+	TaskID       string
+	ProgramID    string
+	PublicInputs []byte
+	NodeID       string
+	PublicKey    ed25519.PublicKey
+	CreatedAt    time.Time
+	Submitted    bool
+}
+
+// Submission 提交记录，通过 /debug/submissions 查看
+type Submission struct {
+	TaskID         string      `json:"task_id"`
+	NodeID         string      `json:"node_id"`
+	ProgramID      string      `json:"program_id"`
+	ProofLen       int         `json:"proof_len"`
+	ProofHash      string      `json:"proof_hash"`
+	SignatureValid bool        `json:"signature_valid"`
+	Telemetry      interface{} `json:"telemetry,omitempty"`
+	Status         int         `json:"status"`
+	Error          string      `json:"error,omitempty"`
+	SubmittedAt    time.Time   `json:"submitted_at"`
+}
+
+// mockUser 模拟用户
+type mockUser struct {
+	UserID        string
+	WalletAddress string
+	NodeIDs       []string
+}
+
+// Server 模拟编排服务
+type Server struct {
+	scenario *Scenario
+
+	mu          sync.Mutex
+	remaining   map[string]int       // 程序ID -> 剩余任务数
+	tasks       map[string]*mockTask // 任务ID -> 任务
+	nodeTasks   map[string][]string  // 节点ID -> 已分配任务ID
+	submissions []Submission
+	users       map[string]*mockUser // 钱包地址/用户ID -> 用户
+	nextTaskID  int
+	nextNodeID  int
+	requests    int // 计入429突发计数的请求数
+	newTaskReqs int
+	submitReqs  int
+	rng         *rand.Rand
+}
+
+// NewServer 创建模拟编排服务
+func NewServer(sc *Scenario) *Server {
+	remaining := make(map[string]int, len(sc.Programs))
+	for program, n := range sc.Programs {
+		remaining[program] = n
+	}
+	return &Server{
+		scenario:   sc,
+		remaining:  remaining,
+		tasks:      make(map[string]*mockTask),
+		nodeTasks:  make(map[string][]string),
+		users:      make(map[string]*mockUser),
+		nextTaskID: 1,
+		nextNodeID: 10000000,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Handler 注册路由，与编排服务 /v3 接口保持一致
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/tasks", s.inject(s.handleTasks))
+	mux.HandleFunc("/v3/tasks/submit", s.inject(s.handleSubmit))
+	mux.HandleFunc("/v3/users", s.inject(s.handleRegisterUser))
+	mux.HandleFunc("/v3/users/", s.inject(s.handleGetUser))
+	mux.HandleFunc("/v3/nodes", s.inject(s.handleRegisterNode))
+	mux.HandleFunc("/debug/submissions", s.handleSubmissions)
+	mux.HandleFunc("/debug/state", s.handleState)
+	return mux
+}
+
+// inject 按场景注入慢响应和429突发
+func (s *Server) inject(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sc := s.scenario
+		if sc.Slow.MaxMs > 0 {
+			s.mu.Lock()
+			delay := sc.Slow.MinMs + s.rng.Intn(sc.Slow.MaxMs-sc.Slow.MinMs+1)
+			s.mu.Unlock()
+			select {
+			case <-time.After(time.Duration(delay) * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if rl := sc.RateLimit; rl.Every > 0 && rl.Burst > 0 {
+			s.mu.Lock()
+			pos := s.requests % (rl.Every + rl.Burst)
+			s.requests++
+			s.mu.Unlock()
+			if pos >= rl.Every {
+				if rl.RetryAfter > 0 {
+					w.Header().Set("Retry-After", strconv.Itoa(rl.RetryAfter))
+				}
+				writeError(w, http.StatusTooManyRequests, "TooManyRequestsError", "Rate limit exceeded")
+				log.Printf("⏳ 429 %s %s", r.Method, r.URL.Path)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// handleTasks GET: 已分配任务（分页）；POST: 分配新任务
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var req pb.GetTasksRequest
+		if !readProto(w, r, &req) {
+			return
+		}
+		s.getTasks(w, &req)
+	case http.MethodPost:
+		var req pb.GetProofTaskRequest
+		if !readProto(w, r, &req) {
+			return
+		}
+		s.newTask(w, &req)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) getTasks(w http.ResponseWriter, req *pb.GetTasksRequest) {
+	s.mu.Lock()
+	var pending []*mockTask
+	for _, id := range s.nodeTasks[req.NodeId] {
+		if t := s.tasks[id]; t != nil && !t.Submitted {
+			pending = append(pending, t)
+		}
+	}
+	s.mu.Unlock()
+
+	offset, _ := strconv.Atoi(req.NextCursor)
+	if offset < 0 || offset > len(pending) {
+		offset = len(pending)
+	}
+	end := offset + s.scenario.PageSize
+	if end > len(pending) {
+		end = len(pending)
+	}
+
+	resp := &pb.GetTasksResponse{}
+	for _, t := range pending[offset:end] {
+		resp.Tasks = append(resp.Tasks, &pb.Task{
+			TaskId:       t.TaskID,
+			ProgramId:    t.ProgramID,
+			PublicInputs: t.PublicInputs,
+			CreatedAt:    timestamppb.New(t.CreatedAt),
+		})
+	}
+	if end < len(pending) {
+		resp.NextCursor = strconv.Itoa(end)
+	}
+	writeProto(w, resp)
+}
+
+func (s *Server) newTask(w http.ResponseWriter, req *pb.GetProofTaskRequest) {
+	s.mu.Lock()
+	s.newTaskReqs++
+	if n := s.scenario.NotFoundEvery; n > 0 && s.newTaskReqs%n == 0 {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "NotFoundError", "No task available")
+		return
+	}
+	program := s.pickProgram()
+	if program == "" {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "NotFoundError", "No task available")
+		return
+	}
+	s.remaining[program]--
+	t := &mockTask{
+		TaskID:       fmt.Sprintf("mock-%06d", s.nextTaskID),
+		ProgramID:    program,
+		PublicInputs: s.publicInputs(program),
+		NodeID:       req.NodeId,
+		PublicKey:    ed25519.PublicKey(req.Ed25519PublicKey),
+		CreatedAt:    time.Now(),
+	}
+	s.nextTaskID++
+	s.tasks[t.TaskID] = t
+	s.nodeTasks[req.NodeId] = append(s.nodeTasks[req.NodeId], t.TaskID)
+	s.mu.Unlock()
+
+	log.Printf("📤 分配任务 %s (%s) -> 节点 %s", t.TaskID, t.ProgramID, t.NodeID)
+	writeProto(w, &pb.GetProofTaskResponse{
+		TaskId:       t.TaskID,
+		ProgramId:    t.ProgramID,
+		PublicInputs: t.PublicInputs,
+	})
+}
+
+// pickProgram 从仍有剩余任务的程序中随机选一个（调用方持锁）
+func (s *Server) pickProgram() string {
+	var programs []string
+	for program, n := range s.remaining {
+		if n > 0 {
+			programs = append(programs, program)
+		}
+	}
+	if len(programs) == 0 {
+		return ""
+	}
+	sort.Strings(programs)
+	return programs[s.rng.Intn(len(programs))]
+}
+
+// publicInputs 生成程序输入（调用方持锁）
+func (s *Server) publicInputs(program string) []byte {
+	switch program {
+	case "fib_input_initial":
+		out := make([]byte, 12)
+		binary.LittleEndian.PutUint32(out[0:4], uint32(10+s.rng.Intn(1000)))
+		binary.LittleEndian.PutUint32(out[4:8], uint32(s.rng.Intn(10)))
+		binary.LittleEndian.PutUint32(out[8:12], uint32(1+s.rng.Intn(10)))
+		return out
+	default:
+		out := make([]byte, 4)
+		binary.LittleEndian.PutUint32(out, uint32(10+s.rng.Intn(1000)))
+		return out
+	}
+}
+
+// handleSubmit 接收证明，校验 proof_hash 和签名并记录
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req pb.SubmitProofRequest
+	if !readProto(w, r, &req) {
+		return
+	}
+
+	sub := Submission{
+		TaskID:      req.TaskId,
+		ProofLen:    len(req.Proof),
+		ProofHash:   req.ProofHash,
+		Telemetry:   req.NodeTelemetry,
+		SubmittedAt: time.Now(),
+	}
+	pub := ed25519.PublicKey(req.Ed25519PublicKey)
+	sub.SignatureValid = len(pub) == ed25519.PublicKeySize &&
+		ed25519.Verify(pub, []byte(req.TaskId+req.ProofHash), req.Signature)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.submitReqs++
+
+	status, name, message := http.StatusOK, "", ""
+	t := s.tasks[req.TaskId]
+	switch {
+	case t == nil || t.Submitted:
+		status, name, message = http.StatusNotFound, "NotFoundError", "Task not found"
+	case s.scenario.SubmitNotFound > 0 && s.submitReqs%s.scenario.SubmitNotFound == 0:
+		status, name, message = http.StatusNotFound, "NotFoundError", "Task not found"
+	case s.scenario.VerifySignatures && fmt.Sprintf("%x", sha256.Sum256(req.Proof)) != req.ProofHash:
+		status, name, message = http.StatusBadRequest, "BadRequestError", "Proof hash mismatch"
+	case s.scenario.VerifySignatures && !sub.SignatureValid:
+		status, name, message = http.StatusUnauthorized, "UnauthorizedError", "Invalid signature"
+	case s.scenario.VerifySignatures && len(t.PublicKey) > 0 && !t.PublicKey.Equal(pub):
+		status, name, message = http.StatusUnauthorized, "UnauthorizedError", "Public key does not match task assignment"
+	}
+	if t != nil {
+		sub.NodeID = t.NodeID
+		sub.ProgramID = t.ProgramID
+	}
+	sub.Status = status
+	sub.Error = message
+	s.submissions = append(s.submissions, sub)
+
+	if status != http.StatusOK {
+		log.Printf("❌ 提交 %s 失败: %d %s", req.TaskId, status, message)
+		writeError(w, status, name, message)
+		return
+	}
+	t.Submitted = true
+	log.Printf("✅ 提交 %s 成功 (节点 %s, 证明 %d 字节)", t.TaskID, t.NodeID, len(req.Proof))
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleRegisterUser POST /v3/users
+func (s *Server) handleRegisterUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req pb.RegisterUserRequest
+	if !readProto(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	u := &mockUser{UserID: req.Uuid, WalletAddress: req.WalletAddress}
+	s.users[strings.ToLower(req.WalletAddress)] = u
+	s.users[req.Uuid] = u
+	s.mu.Unlock()
+	log.Printf("👤 注册用户 %s (%s)", req.Uuid, req.WalletAddress)
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleRegisterNode POST /v3/nodes
+func (s *Server) handleRegisterNode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req pb.RegisterNodeRequest
+	if !readProto(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	u := s.users[req.UserId]
+	if u == nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "NotFoundError", "User not found")
+		return
+	}
+	nodeID := strconv.Itoa(s.nextNodeID)
+	s.nextNodeID++
+	u.NodeIDs = append(u.NodeIDs, nodeID)
+	s.mu.Unlock()
+	log.Printf("🖥️ 注册节点 %s (用户 %s)", nodeID, req.UserId)
+	writeProto(w, &pb.RegisterNodeResponse{NodeId: nodeID})
+}
+
+// handleGetUser GET /v3/users/{钱包地址或用户ID}?nodes_cursor=
+func (s *Server) handleGetUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/v3/users/")
+	s.mu.Lock()
+	u := s.users[key]
+	if u == nil {
+		u = s.users[strings.ToLower(key)]
+	}
+	var nodeIDs []string
+	if u != nil {
+		nodeIDs = append(nodeIDs, u.NodeIDs...)
+	}
+	s.mu.Unlock()
+	if u == nil {
+		writeError(w, http.StatusNotFound, "NotFoundError", "User not found")
+		return
+	}
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("nodes_cursor"))
+	if offset < 0 || offset > len(nodeIDs) {
+		offset = len(nodeIDs)
+	}
+	end := offset + s.scenario.PageSize
+	if end > len(nodeIDs) {
+		end = len(nodeIDs)
+	}
+	resp := &pb.UserResponse{UserId: u.UserID, WalletAddress: u.WalletAddress}
+	for _, id := range nodeIDs[offset:end] {
+		resp.Nodes = append(resp.Nodes, &pb.Node{NodeId: id, NodeType: pb.NodeType_CLI_PROVER})
+	}
+	if end < len(nodeIDs) {
+		resp.NodesNextCursor = strconv.Itoa(end)
+	}
+	writeProto(w, resp)
+}
+
+// handleSubmissions GET /debug/submissions 列出全部提交记录
+func (s *Server) handleSubmissions(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	subs := append([]Submission(nil), s.submissions...)
+	s.mu.Unlock()
+	writeJSON(w, subs)
+}
+
+// handleState GET /debug/state 任务池状态
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	state := map[string]interface{}{
+		"remaining":   s.remaining,
+		"assigned":    len(s.tasks),
+		"submissions": len(s.submissions),
+		"requests":    s.requests,
+	}
+	data, err := json.Marshal(state)
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// readProto 读取并解析protobuf请求体，失败时写入400
+func readProto(w http.ResponseWriter, r *http.Request, msg proto.Message) bool {
+	data, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = proto.Unmarshal(data, msg)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "BadRequestError", err.Error())
+		return false
+	}
+	return true
+}
+
+func writeProto(w http.ResponseWriter, msg proto.Message) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError 按编排服务的JSON错误格式返回错误
+func writeError(w http.ResponseWriter, status int, name, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"name":     name,
+			"message":  message,
+			"httpCode": status,
+		},
+	})
+}
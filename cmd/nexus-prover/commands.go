@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"nexus-prover/internal/api"
+	"nexus-prover/internal/config"
+)
+
+// runCommand 处理子命令，返回 true 表示已处理
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "register-user":
+		cmdRegisterUser(args[1:])
+	case "register-node":
+		cmdRegisterNode(args[1:])
+	case "list-nodes":
+		cmdListNodes(args[1:])
+	case "keys":
+		cmdKeys(args[1:])
+	case "config":
+		cmdConfig(args[1:])
+	case "dlq":
+		cmdDLQ(args[1:])
+	default:
+		return false
+	}
+	return true
+}
+
+// commandFlags 子命令通用参数
+type commandFlags struct {
+	fs          *flag.FlagSet
+	configPath  *string
+	configFlags *config.FlagBinding
+}
+
+// newCommandFlags 创建子命令参数集，包含 -c/-config、-env 以及每个配置字段的覆盖参数
+func newCommandFlags(name string) *commandFlags {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cf := &commandFlags{fs: fs}
+	cf.configPath = fs.String("c", "config.json", "配置文件路径 (默认: config.json)")
+	fs.StringVar(cf.configPath, "config", "config.json", "配置文件路径 (默认: config.json)")
+	cf.configFlags = config.BindFlags(fs)
+	return cf
+}
+
+// parse 解析参数
+func (cf *commandFlags) parse(args []string) {
+	if err := cf.fs.Parse(args); err != nil {
+		os.Exit(2)
+	}
+}
+
+// load 分层加载配置: 配置文件、环境变量、命令行参数
+func (cf *commandFlags) load() *config.Config {
+	cfg, err := cf.tryLoad()
+	if err != nil {
+		log.Fatalf("❌ 加载配置文件失败: %v", err)
+	}
+	return cfg
+}
+
+// tryLoad 加载配置，校验失败时同时返回已解析的配置
+func (cf *commandFlags) tryLoad() (*config.Config, error) {
+	return config.Load(config.LoadOptions{Path: *cf.configPath, Flags: cf.configFlags.Values()})
+}
+
+// client 根据配置创建API客户端
+func (cf *commandFlags) client(cfg *config.Config) *api.Client {
+	apiClient, err := api.NewClient(cfg)
+	if err != nil {
+		log.Fatalf("❌ 初始化API客户端失败: %v", err)
+	}
+	fmt.Printf("编排服务: %s (%s)\n", cfg.Environment, apiClient.Profile().URL())
+	return apiClient
+}
+
+// commandContext 子命令使用的context，收到 SIGINT/SIGTERM 时取消，立即中断网络请求
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
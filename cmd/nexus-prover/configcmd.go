@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"nexus-prover/internal/config"
+)
+
+// cmdConfig 配置文件管理: config validate / print
+func cmdConfig(args []string) {
+	if len(args) == 0 {
+		printConfigUsage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "validate":
+		cmdConfigValidate(args[1:])
+	case "print":
+		cmdConfigPrint(args[1:])
+	default:
+		fmt.Printf("未知的 config 子命令: %s\n", args[0])
+		printConfigUsage()
+		os.Exit(2)
+	}
+}
+
+func printConfigUsage() {
+	fmt.Println("用法:")
+	fmt.Println("  config validate [-c 配置文件] [-env 环境] [-<字段> 值]")
+	fmt.Println("  config print [--effective] [-c 配置文件] [-env 环境] [-<字段> 值]")
+}
+
+// cmdConfigValidate 校验配置文件（含环境变量和命令行覆盖），一次列出全部问题，有错误时以状态码1退出
+func cmdConfigValidate(args []string) {
+	cf := newCommandFlags("config validate")
+	cf.parse(args)
+
+	cfg, err := cf.tryLoad()
+	var verr *config.ValidationError
+	if err != nil && !errors.As(err, &verr) {
+		fmt.Printf("❌ %s: %v\n", *cf.configPath, err)
+		os.Exit(1)
+	}
+
+	var problems []string
+	if verr != nil {
+		for _, fe := range verr.Errors {
+			problems = append(problems, fe.Error())
+		}
+	}
+	// 启动时才检查的项目: 节点列表
+	if len(cfg.Nodes) == 0 && !cfg.DiscoveryEnabled() {
+		problems = append(problems, "node_ids: 不能为空（或启用 node_discovery）")
+	}
+	if len(problems) > 0 {
+		fmt.Printf("❌ %s: %d 个错误\n", *cf.configPath, len(problems))
+		for _, p := range problems {
+			fmt.Printf("  %s\n", p)
+		}
+		os.Exit(1)
+	}
+
+	profile, _ := cfg.ActiveProfile()
+	fmt.Printf("✅ %s 校验通过\n", *cf.configPath)
+	fmt.Printf("   节点数量: %d, 证明计算worker数量: %d, 编排服务: %s (%s)\n",
+		len(cfg.Nodes), cfg.ProverWorkers, cfg.Environment, profile.URL())
+}
+
+// cmdConfigPrint 输出生效的配置（JSON），--effective 时逐项列出值和来源
+func cmdConfigPrint(args []string) {
+	cf := newCommandFlags("config print")
+	effective := cf.fs.Bool("effective", false, "逐项列出生效的值及来源（默认值 / 配置文件 / 环境变量 / 命令行）")
+	cf.parse(args)
+	cfg := cf.load()
+
+	if !*effective {
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Println("优先级: 默认值 < 配置文件 < 环境变量 < 命令行参数")
+	for _, ev := range cfg.Effective() {
+		fmt.Printf("  %-28s = %-40s # %s\n", ev.Field.Path, ev.Value, ev.Source)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"nexus-prover/internal/api"
+	"nexus-prover/internal/config"
+	"nexus-prover/internal/deadletter"
+	"nexus-prover/internal/utils"
+)
+
+// cmdDLQ 放弃重试的证明（死信目录）管理: dlq list / show / resubmit / purge
+func cmdDLQ(args []string) {
+	if len(args) == 0 {
+		printDLQUsage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "list":
+		cmdDLQList(args[1:])
+	case "show":
+		cmdDLQShow(args[1:])
+	case "resubmit":
+		cmdDLQResubmit(args[1:])
+	case "purge":
+		cmdDLQPurge(args[1:])
+	default:
+		fmt.Printf("未知的 dlq 子命令: %s\n", args[0])
+		printDLQUsage()
+		os.Exit(2)
+	}
+}
+
+func printDLQUsage() {
+	fmt.Println("用法:")
+	fmt.Println("  dlq list [-c 配置文件]")
+	fmt.Println("  dlq show -task ID [-c 配置文件]")
+	fmt.Println("  dlq resubmit (-task ID | -all) [-c 配置文件]")
+	fmt.Println("  dlq purge (-task ID | -older 时长 | -all) [-c 配置文件]")
+}
+
+// openDeadLetters 打开配置文件 dead_letter_dir 指定的死信目录
+func openDeadLetters(cfg *config.Config) *deadletter.Store {
+	dlq, err := deadletter.Open(cfg.DeadLetterDir)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	return dlq
+}
+
+// cmdDLQList 列出全部死信，按放弃时间排序
+func cmdDLQList(args []string) {
+	cf := newCommandFlags("dlq list")
+	cf.parse(args)
+	dlq := openDeadLetters(cf.load())
+
+	entries, err := dlq.List()
+	if err != nil {
+		log.Fatalf("❌ 读取死信目录失败: %v", err)
+	}
+	for _, e := range entries {
+		fmt.Printf("  %-36s 节点 %-10s 程序 %-20s %s 重试%d次 %-12s %8d 字节  %s\n",
+			e.TaskID, e.NodeID, e.ProgramID, e.DeadAt.Local().Format("2006-01-02 15:04:05"),
+			e.RetryCount, e.ErrorClass, e.ProofSize, e.Reason)
+	}
+	fmt.Printf("共 %d 个死信，目录: %s\n", len(entries), dlq.Dir())
+}
+
+// cmdDLQShow 显示死信详情（不输出证明内容）
+func cmdDLQShow(args []string) {
+	cf := newCommandFlags("dlq show")
+	taskID := cf.fs.String("task", "", "任务ID")
+	cf.parse(args)
+	if *taskID == "" {
+		log.Fatal("❌ 必须指定任务ID (-task)")
+	}
+	dlq := openDeadLetters(cf.load())
+
+	e, err := dlq.Get(*taskID)
+	if err != nil {
+		log.Fatalf("❌ 读取任务 %s 的死信失败: %v", *taskID, err)
+	}
+	fmt.Printf("任务ID:       %s\n", e.TaskID)
+	fmt.Printf("节点ID:       %s\n", e.NodeID)
+	fmt.Printf("程序ID:       %s\n", e.ProgramID)
+	fmt.Printf("已分配任务:   %v\n", e.Existing)
+	fmt.Printf("任务创建时间: %s\n", formatTime(e.CreatedAt))
+	fmt.Printf("首次失败时间: %s\n", formatTime(e.FailedAt))
+	fmt.Printf("放弃时间:     %s\n", formatTime(e.DeadAt))
+	fmt.Printf("重试次数:     %d\n", e.RetryCount)
+	fmt.Printf("手动重新提交: %d 次失败\n", e.Resubmits)
+	fmt.Printf("放弃原因:     %s\n", e.Reason)
+	fmt.Printf("错误类型:     %s\n", e.ErrorClass)
+	fmt.Printf("最后错误:     %s\n", e.LastError)
+	fmt.Printf("证明:         %d 字节, sha256 %s\n", e.ProofSize, e.ProofSHA256)
+}
+
+// formatTime 本地时间，零值显示为 -
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// cmdDLQResubmit 用任务所属节点的密钥重新提交死信中的证明：成功或任务已不存在（404）时删除死信，
+// 其他错误记录到死信中保留
+func cmdDLQResubmit(args []string) {
+	cf := newCommandFlags("dlq resubmit")
+	taskID := cf.fs.String("task", "", "任务ID")
+	all := cf.fs.Bool("all", false, "重新提交全部死信")
+	cf.parse(args)
+	if (*taskID == "") == !*all {
+		log.Fatal("❌ 必须指定 -task ID 或 -all 之一")
+	}
+	cfg := cf.load()
+	dlq := openDeadLetters(cfg)
+
+	taskIDs := []string{*taskID}
+	if *all {
+		entries, err := dlq.List()
+		if err != nil {
+			log.Fatalf("❌ 读取死信目录失败: %v", err)
+		}
+		taskIDs = taskIDs[:0]
+		for _, e := range entries {
+			taskIDs = append(taskIDs, e.TaskID)
+		}
+	}
+	if len(taskIDs) == 0 {
+		fmt.Printf("死信目录 %s 为空\n", dlq.Dir())
+		return
+	}
+
+	apiClient := cf.client(cfg)
+	signer, _, err := newSigner(cfg)
+	if err != nil {
+		log.Fatalf("❌ 初始化签名器失败: %v", err)
+	}
+	apiClient.SetSigner(signer)
+	ctx, cancel := commandContext()
+	defer cancel()
+
+	submitted, removed, failed := 0, 0, 0
+	for _, id := range taskIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		e, err := dlq.Get(id)
+		if err != nil {
+			fmt.Printf("❌ %s: %v\n", id, err)
+			failed++
+			continue
+		}
+		err = apiClient.SubmitProof(ctx, e.Task(), e.Proof)
+		utils.ClearProofData(e.Proof)
+		var notFound *api.TaskNotFoundError
+		switch {
+		case err == nil:
+			fmt.Printf("✅ %s 提交成功\n", id)
+			submitted++
+		case errors.As(err, &notFound):
+			fmt.Printf("🗑️ %s 任务已不存在(404)，删除死信: %v\n", id, err)
+			removed++
+		default:
+			fmt.Printf("❌ %s 提交失败: %v\n", id, err)
+			failed++
+			if ctx.Err() == nil {
+				recordResubmitFailure(dlq, id, err)
+			}
+			continue
+		}
+		if err := dlq.Remove(id); err != nil && !errors.Is(err, deadletter.ErrNotFound) {
+			fmt.Printf("⚠️ %s 删除死信失败: %v\n", id, err)
+		}
+	}
+	fmt.Printf("提交成功 %d 个，任务已不存在 %d 个，失败 %d 个\n", submitted, removed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// recordResubmitFailure 手动重新提交失败时更新死信中的错误和失败次数
+func recordResubmitFailure(dlq *deadletter.Store, taskID string, submitErr error) {
+	e, err := dlq.Get(taskID)
+	if err != nil {
+		return
+	}
+	e.Resubmits++
+	e.LastError = submitErr.Error()
+	if err := dlq.Put(e); err != nil {
+		fmt.Printf("⚠️ %s 更新死信失败: %v\n", taskID, err)
+	}
+}
+
+// cmdDLQPurge 删除死信: 指定任务、放弃时间早于 -older 的全部死信，或全部死信
+func cmdDLQPurge(args []string) {
+	cf := newCommandFlags("dlq purge")
+	taskID := cf.fs.String("task", "", "任务ID")
+	older := cf.fs.Duration("older", 0, "删除放弃时间早于该时长之前的死信，如 72h")
+	all := cf.fs.Bool("all", false, "删除全部死信")
+	cf.parse(args)
+	selected := 0
+	for _, set := range []bool{*taskID != "", *older > 0, *all} {
+		if set {
+			selected++
+		}
+	}
+	if selected != 1 {
+		log.Fatal("❌ 必须指定 -task ID、-older 时长 或 -all 之一")
+	}
+	dlq := openDeadLetters(cf.load())
+
+	if *taskID != "" {
+		if err := dlq.Remove(*taskID); err != nil {
+			log.Fatalf("❌ 删除任务 %s 的死信失败: %v", *taskID, err)
+		}
+		fmt.Printf("✅ 已删除任务 %s 的死信\n", *taskID)
+		return
+	}
+	entries, err := dlq.List()
+	if err != nil {
+		log.Fatalf("❌ 读取死信目录失败: %v", err)
+	}
+	cutoff := time.Now().Add(-*older)
+	purged := 0
+	for _, e := range entries {
+		if !*all && !e.DeadAt.Before(cutoff) {
+			continue
+		}
+		if err := dlq.Remove(e.TaskID); err != nil && !errors.Is(err, deadletter.ErrNotFound) {
+			log.Fatalf("❌ 删除任务 %s 的死信失败: %v", e.TaskID, err)
+		}
+		purged++
+	}
+	fmt.Printf("✅ 已删除 %d 个死信，目录: %s\n", purged, dlq.Dir())
+}
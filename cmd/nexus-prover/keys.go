@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"nexus-prover/internal/config"
+	"nexus-prover/internal/keystore"
+)
+
+// cmdKeys 节点密钥管理: keys list / show / rotate / export
+func cmdKeys(args []string) {
+	if len(args) == 0 {
+		printKeysUsage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "list":
+		cmdKeysList(args[1:])
+	case "show":
+		cmdKeysShow(args[1:])
+	case "rotate":
+		cmdKeysRotate(args[1:])
+	case "export":
+		cmdKeysExport(args[1:])
+	default:
+		fmt.Printf("未知的 keys 子命令: %s\n", args[0])
+		printKeysUsage()
+		os.Exit(2)
+	}
+}
+
+func printKeysUsage() {
+	fmt.Println("用法:")
+	fmt.Println("  keys list [-c 配置文件]")
+	fmt.Println("  keys show -node ID [-c 配置文件]")
+	fmt.Println("  keys rotate -node ID [-c 配置文件]")
+	fmt.Println("  keys export -node ID [-o 文件] [-c 配置文件]")
+}
+
+// openKeyStore 打开配置文件 key_dir 指定的密钥库，口令来自环境变量
+func openKeyStore(cfg *config.Config) *keystore.Store {
+	keys, err := keystore.Open(cfg.KeyDir, os.Getenv(config.KEY_PASSPHRASE_ENV))
+	if err != nil {
+		log.Fatalf("❌ 打开密钥目录失败: %v", err)
+	}
+	keys.SetKeyFiles(cfg.KeyFiles())
+	return keys
+}
+
+// nodeFlag 解析 -node 参数，未指定时报错退出
+func nodeFlag(cf *commandFlags, args []string) string {
+	nodeID := cf.fs.String("node", "", "节点ID")
+	cf.parse(args)
+	if *nodeID == "" {
+		log.Fatal("❌ 必须指定节点ID (-node)")
+	}
+	return *nodeID
+}
+
+// cmdKeysList 列出全部节点密钥，并标出配置文件中尚未生成密钥的节点
+func cmdKeysList(args []string) {
+	cf := newCommandFlags("keys list")
+	cf.parse(args)
+	cfg := cf.load()
+	keys := openKeyStore(cfg)
+
+	infos, err := keys.List()
+	if err != nil {
+		log.Fatalf("❌ 读取密钥目录失败: %v", err)
+	}
+	found := make(map[string]bool, len(infos))
+	for _, info := range infos {
+		found[info.NodeID] = true
+		mark := ""
+		if info.Encrypted {
+			mark = " (已加密)"
+		}
+		fmt.Printf("  %-16s %x  %s%s\n", info.NodeID, info.PublicKey, info.CreatedAt.Local().Format("2006-01-02 15:04:05"), mark)
+	}
+	for _, nodeID := range cfg.NodeIDs() {
+		if !found[nodeID] {
+			fmt.Printf("  %-16s (未生成，首次运行时自动生成)\n", nodeID)
+		}
+	}
+	fmt.Printf("共 %d 个密钥，目录: %s\n", len(infos), keys.Dir())
+}
+
+// cmdKeysShow 显示节点密钥信息
+func cmdKeysShow(args []string) {
+	cf := newCommandFlags("keys show")
+	nodeID := nodeFlag(cf, args)
+	keys := openKeyStore(cf.load())
+
+	info, err := keys.Info(nodeID)
+	if err != nil {
+		log.Fatalf("❌ 读取节点 %s 的密钥失败: %v", nodeID, err)
+	}
+	fmt.Printf("节点ID:   %s\n", info.NodeID)
+	fmt.Printf("公钥:     %x\n", info.PublicKey)
+	fmt.Printf("创建时间: %s\n", info.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("已加密:   %v\n", info.Encrypted)
+	fmt.Printf("文件:     %s\n", info.Path)
+}
+
+// cmdKeysRotate 为节点生成新密钥，旧密钥备份保留
+func cmdKeysRotate(args []string) {
+	cf := newCommandFlags("keys rotate")
+	nodeID := nodeFlag(cf, args)
+	keys := openKeyStore(cf.load())
+
+	priv, backup, err := keys.Rotate(nodeID)
+	if err != nil {
+		log.Fatalf("❌ 轮换节点 %s 的密钥失败: %v", nodeID, err)
+	}
+	if backup != "" {
+		fmt.Printf("📦 旧密钥已备份: %s\n", backup)
+	}
+	fmt.Printf("✅ 节点 %s 新公钥: %x\n", nodeID, priv.Public())
+	fmt.Println("⚠️ 已分配给旧公钥的任务需要用旧密钥提交，请在没有未完成任务时轮换")
+}
+
+// exportedKey 导出的明文密钥
+type exportedKey struct {
+	NodeID     string `json:"node_id"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"` // Ed25519 seed（hex）
+}
+
+// cmdKeysExport 导出节点私钥（明文 JSON），默认输出到标准输出
+func cmdKeysExport(args []string) {
+	cf := newCommandFlags("keys export")
+	output := cf.fs.String("o", "", "输出文件 (默认: 标准输出)")
+	nodeID := nodeFlag(cf, args)
+	keys := openKeyStore(cf.load())
+
+	priv, err := keys.Load(nodeID)
+	if err != nil {
+		log.Fatalf("❌ 读取节点 %s 的密钥失败: %v", nodeID, err)
+	}
+	data, err := json.MarshalIndent(exportedKey{
+		NodeID:     nodeID,
+		PublicKey:  fmt.Sprintf("%x", priv.Public()),
+		PrivateKey: hex.EncodeToString(priv.Seed()),
+	}, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0600); err != nil {
+		log.Fatalf("❌ 写入文件失败: %v", err)
+	}
+	fmt.Printf("✅ 节点 %s 的私钥已导出到 %s（明文，请妥善保管）\n", nodeID, *output)
+}
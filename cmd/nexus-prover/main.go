@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"crypto/ed25519"
-	"crypto/rand"
 	"flag"
 	"fmt"
 	"log"
@@ -14,7 +12,11 @@ import (
 	"syscall"
 	"time"
 
+	"nexus-prover/internal/api"
 	"nexus-prover/internal/config"
+	"nexus-prover/internal/deadletter"
+	"nexus-prover/internal/keystore"
+	"nexus-prover/internal/telemetry"
 	"nexus-prover/internal/utils"
 	"nexus-prover/internal/worker"
 	"nexus-prover/pkg/types"
@@ -27,11 +29,18 @@ func main() {
 		return
 	}
 
+	// 子命令: register-user / register-node / list-nodes / keys / config
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	// 定义命令行参数
 	configPath := flag.String("c", "config.json", "配置文件路径 (默认: config.json)")
 	configPathLong := flag.String("config", "config.json", "配置文件路径 (默认: config.json)")
 	processIsolation := flag.Bool("ps", false, "启用进程隔离模式（使用官方zkVM生成proof）")
 	processIsolationLong := flag.Bool("process-isolation", false, "启用进程隔离模式（使用官方zkVM生成proof）")
+	watchConfig := flag.Int("watch", 0, "每隔N秒检查配置文件，修改后自动热更新 (默认: 0，只在收到 SIGHUP 时重新加载)")
+	configFlags := config.BindFlags(flag.CommandLine) // 每个配置字段对应一个参数，如 -prover-workers
 	showHelp := flag.Bool("h", false, "显示帮助信息")
 	showHelpLong := flag.Bool("help", false, "显示帮助信息")
 	showVersion := flag.Bool("v", false, "显示版本信息")
@@ -60,32 +69,84 @@ func main() {
 		cfgFile = *configPath
 	}
 
-	// 检查配置文件是否存在
+	// 检查配置文件是否存在，未指定且默认文件不存在时只使用环境变量和命令行参数
 	if _, err := os.Stat(cfgFile); os.IsNotExist(err) {
-		log.Fatalf("❌ 配置文件不存在: %s", cfgFile)
+		if cfgFile != "config.json" {
+			log.Fatalf("❌ 配置文件不存在: %s", cfgFile)
+		}
+		utils.LogWithTime("⚠️ 未找到配置文件 %s，只使用环境变量和命令行参数", cfgFile)
+		cfgFile = ""
 	}
 
-	cfg, err := config.LoadConfig(cfgFile)
+	// 分层加载: 默认值 < 配置文件 < 环境变量 NEXUS_* < 命令行参数
+	loadOpts := config.LoadOptions{Path: cfgFile, Flags: configFlags.Values()}
+	cfg, err := config.Load(loadOpts)
 	if err != nil {
 		log.Fatalf("❌ 加载配置文件失败: %v", err)
 	}
 
+	// 加载配置后立即接管 SIGHUP，启动期间收到的信号留待热更新开始后处理，不会按默认行为终止进程
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
 	// 验证配置
-	if len(cfg.NodeIDs) == 0 {
+	if len(cfg.Nodes) == 0 && !cfg.DiscoveryEnabled() {
 		log.Fatal("配置错误: node_ids 数组不能为空")
 	}
 
+	apiClient, err := api.NewClient(cfg)
+	if err != nil {
+		log.Fatalf("❌ 初始化API客户端失败: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 节点遥测: 启动时运行FLOPS基准测试，读取内存上限
+	collector := telemetry.New(cfg.TelemetryLocation)
+	flops := collector.RunBenchmark(500 * time.Millisecond)
+	collector.SetNodeLocations(cfg.NodeLocations())
+	apiClient.SetTelemetry(collector)
+	utils.LogWithTime("📡 节点遥测: %.2f GFLOPS, 内存上限 %s, 位置 %s",
+		float64(flops)/1e9, telemetry.FormatBytes(telemetry.MemoryCapacity()), collector.Location())
+
+	// 节点自动发现: 启动时先同步获取一次用户节点列表
+	nodes := worker.NewNodeSet(cfg.NodeDiscovery, cfg.Nodes)
+	if cfg.DiscoveryEnabled() {
+		discovered, err := worker.DiscoverNodes(ctx, apiClient, cfg)
+		if err != nil {
+			if len(cfg.Nodes) == 0 {
+				log.Fatalf("❌ 节点自动发现失败且 node_ids 为空: %v", err)
+			}
+			utils.LogWithTime("⚠️ 节点自动发现失败，使用配置的 node_ids: %v", err)
+		} else {
+			nodes.SetDiscovered(discovered)
+			utils.LogWithTime("🔍 自动发现 %d 个CLI节点 (模式: %s)", len(discovered), cfg.NodeDiscovery)
+		}
+	}
+	if nodes.Len() == 0 {
+		log.Fatal("配置错误: 没有可用的节点ID")
+	}
+
 	utils.LogWithTime("📋 配置信息:")
 	utils.LogWithTime("   配置文件: %s", cfgFile)
-	utils.LogWithTime("   节点IDs: %v", cfg.NodeIDs)
+	utils.LogWithTime("   节点IDs: %v", nodes.List())
 	utils.LogWithTime("   用户ID: %s", cfg.UserID)
 	utils.LogWithTime("   钱包地址: %s", cfg.WalletAddress)
+	utils.LogWithTime("   编排服务环境: %s (%s)", cfg.Environment, apiClient.Profile().URL())
+	if cfg.Signer.Type == config.SIGNER_SOCKET {
+		utils.LogWithTime("   签名服务: %s", cfg.Signer.Socket)
+	} else {
+		utils.LogWithTime("   密钥目录: %s", cfg.KeyDir)
+	}
 	utils.LogWithTime("   请求间隔: %d 秒", cfg.RequestDelay)
+	utils.LogWithTime("   客户端限流: 全局 %d 次/%d秒, 每节点 %d 次/%d秒 (-1 表示不限制)",
+		cfg.RateLimit.GlobalRequests, cfg.RateLimit.Window, cfg.RateLimit.NodeRequests, cfg.RateLimit.Window)
 	utils.LogWithTime("   证明计算worker数量: %d", cfg.ProverWorkers)
-	utils.LogWithTime("   节点数量: %d", len(cfg.NodeIDs))
+	utils.LogWithTime("   节点数量: %d", nodes.Len())
 	utils.LogWithTime("   🆕 任务队列调度模式")
 	utils.LogWithTime("   🆕 队列容量: %d", cfg.TaskQueueCapacity)
-	utils.LogWithTime("   🆕 固定180秒间隔获取任务")
+	utils.LogWithTime("   🆕 固定%d秒间隔获取任务，每次最多 %d 个", cfg.TaskFetchInterval, cfg.BatchSize)
 	utils.LogWithTime("   🆕 优先获取已分配任务")
 	utils.LogWithTime("   🆕 内存优化: 提交成功后立即释放证明数据")
 	utils.LogWithTime("   按 Ctrl+C 优雅停止程序")
@@ -93,28 +154,46 @@ func main() {
 	// 显示初始内存使用情况
 	utils.LogWithTime("💾 初始进程物理内存: %.2fMB", utils.GetProcMemUsage())
 
-	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	// 节点签名: 进程内密钥库或外部签名服务，每个节点独立的 Ed25519 密钥
+	signer, keys, err := newSigner(cfg)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("❌ 初始化签名器失败: %v", err)
 	}
+	for _, nodeID := range nodes.List() {
+		pub, err := signer.PublicKey(ctx, nodeID)
+		if err != nil {
+			log.Fatalf("❌ 获取节点 %s 的公钥失败: %v", nodeID, err)
+		}
+		utils.LogWithTime("🔑 节点 %s 公钥: %x", nodeID, pub)
+	}
+	apiClient.SetSigner(signer)
 
-	ctx, cancel := context.WithCancel(context.Background())
 	var wg sync.WaitGroup
 	var acceptingTasks int32 = 1
 
 	// 创建任务队列
-	taskQueue := types.NewTaskQueue(cfg.TaskQueueCapacity, 100)
-	utils.LogWithTime("📦 任务队列已创建 (容量: %d), 提交失败重试队列容量: %d", cfg.TaskQueueCapacity, 100)
+	taskQueue := newTaskQueue(cfg)
+	taskQueue.SetOnDone(func(task *types.Task) { collector.Forget(task.TaskID) })
+	utils.LogWithTime("📦 任务队列已创建 (容量: %d, 调度: %s, 节点公平: %s), 提交失败重试队列容量: %d",
+		cfg.TaskQueueCapacity, cfg.Queue.Scheduling, cfg.Queue.Fairness, cfg.RetryQueueCapacity)
 
 	utils.LogWithTime("🔄 防止任务获取限速, 等待3分钟...")
 	// utils.SleepWithContext(ctx, time.Duration(3)*time.Minute) // 为防止任务获取限速，让worker等待3分钟
 
 	// 启动任务获取worker
 	wg.Add(1)
-	go worker.TaskFetcher(ctx, cfg.NodeIDs, pub, taskQueue, cfg.RequestDelay, &wg, &acceptingTasks)
+	settings := worker.NewSettings(cfg)
+	go worker.TaskFetcher(ctx, apiClient, cfg, nodes, taskQueue, settings, &wg, &acceptingTasks)
+
+	// 启动节点自动发现worker
+	if cfg.DiscoveryEnabled() {
+		wg.Add(1)
+		go worker.NodeDiscovery(ctx, apiClient, cfg, nodes, &wg)
+	}
 
 	// 检查是否使用进程隔离模式
 	useProcessIsolation := *processIsolation || *processIsolationLong
+	var pool *worker.WorkerPool
 	if useProcessIsolation {
 		// 使用进程隔离模式
 		utils.LogWithTime("🔄 启用进程隔离模式")
@@ -126,37 +205,33 @@ func main() {
 		}
 
 		// 创建进程证明器
-		prover := worker.NewProcessProver(execPath, 300, 3) // 5分钟超时，最多3次重启
-
-		// 启动进程隔离的证明计算worker池
-		for i := 0; i < cfg.ProverWorkers; i++ {
-			wg.Add(1)
-			utils.LogWithTime("🔧 启动进程隔离证明计算worker-%d", i)
-			go func(workerID int) {
-				worker.ProcessWorker(ctx, workerID, priv, taskQueue, &wg, prover)
-			}(i)
-		}
+		prover := worker.NewProcessProver(execPath, cfg.ProcessIsolation.MaxLifetime, cfg.ProcessIsolation.MaxRestarts, collector)
+		utils.LogWithTime("   子进程超时: %d秒, 连续失败上限: %d次", cfg.ProcessIsolation.MaxLifetime, cfg.ProcessIsolation.MaxRestarts)
+
+		// 进程隔离的证明计算worker池
+		pool = worker.NewWorkerPool(&wg, func(workerID int, stop <-chan struct{}) {
+			utils.LogWithTime("🔧 启动进程隔离证明计算worker-%d", workerID)
+			worker.ProcessWorker(ctx, apiClient, workerID, taskQueue, &wg, prover, stop)
+		})
 	} else {
 		// 使用普通模式
 		utils.LogWithTime("🔧 启用普通模式")
 
-		// 启动证明计算worker池
-		for i := 0; i < cfg.ProverWorkers; i++ {
-			wg.Add(1)
-			utils.LogWithTime("🔧 启动证明计算worker-%d", i)
-			go func(workerID int) {
-				worker.ProverWorker(ctx, workerID, priv, taskQueue, cfg.ProverSubmitWaitSecond, &wg)
-			}(i)
-		}
+		// 证明计算worker池
+		pool = worker.NewWorkerPool(&wg, func(workerID int, stop <-chan struct{}) {
+			utils.LogWithTime("🔧 启动证明计算worker-%d", workerID)
+			worker.ProverWorker(ctx, apiClient, workerID, taskQueue, settings, collector, &wg, stop)
+		})
 	}
+	pool.Resize(cfg.ProverWorkers)
 
 	// 启动重试worker：
 	wg.Add(1)
-	go worker.RetryWorker(ctx, taskQueue, priv, &wg)
+	go worker.RetryWorker(ctx, apiClient, taskQueue, &wg)
 
 	// 启动周期统计goroutine
-	utils.LogWithTime("📊 启动周期统计 (间隔: %d秒)", worker.STATS_INTERVAL)
-	go worker.PeriodicStats(ctx, taskQueue)
+	utils.LogWithTime("📊 启动周期统计 (间隔: %d秒)", cfg.StatsInterval)
+	go worker.PeriodicStats(ctx, taskQueue, cfg.StatsInterval)
 
 	// 控制useLocal
 	useLocal := !useProcessIsolation
@@ -167,10 +242,14 @@ func main() {
 		utils.LogWithTime("✅ 当前使用官方zkVM生成proof，可提交到服务端验证。")
 	}
 
+	// 配置热更新: SIGHUP 或 -watch 检测到配置文件修改时重新加载
+	reloader := &configReloader{opts: loadOpts, current: cfg, nodes: nodes, pool: pool, settings: settings, keys: keys, collector: collector, queue: taskQueue}
+	go reloader.run(ctx, hup, time.Duration(*watchConfig)*time.Second)
+
 	// 设置信号处理
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-	utils.LogWithTime("🚀 程序已启动，等待任务...")
+	utils.LogWithTime("🚀 程序已启动，等待任务... (kill -HUP %d 重新加载配置)", os.Getpid())
 
 	sig := <-c // 等待信号
 	utils.LogWithTime("🛑 收到信号 %v，正在优雅关闭...", sig)
@@ -188,6 +267,9 @@ func main() {
 	case <-time.After(3 * time.Minute):
 		utils.LogWithTime("⚠️  等待超时（3分钟），强制退出")
 	}
+	if err := taskQueue.Close(); err != nil {
+		utils.LogWithTime("⚠️ %v", err)
+	}
 	utils.LogWithTime("👋 程序已退出")
 
 	// 在MainEntry退出前输出统计
@@ -196,21 +278,155 @@ func main() {
 
 	// 输出队列统计
 	queued, processed, failed := taskQueue.GetStats()
-	utils.LogWithTime("📦 队列统计 - 队列中: %d, 已处理: %d, 失败: %d", queued, processed, failed)
+	pendingRetries, retryOverflow, retryExhausted := taskQueue.RetryStats()
+	utils.LogWithTime("📦 队列统计 - 队列中: %d, 已处理: %d, 失败: %d, 重复: %d, 过期丢弃: %d, 待重试: %d, 重试溢出: %d, 重试用完: %d",
+		queued, processed, failed, taskQueue.Duplicates(), taskQueue.Expired(), pendingRetries, retryOverflow, retryExhausted)
 
 	// 显示最终内存使用情况
 	utils.LogWithTime("💾 最终进程物理内存: %.2fMB", utils.GetProcMemUsage())
 }
 
+// newTaskQueue 根据 queue.backend 创建任务队列，磁盘队列启动时恢复上次未完成的任务；
+// queue.scheduling 为 priority 时按 queue.priority 打分出队；提交失败按 retry_backoff 退避重试，
+// 放弃重试的证明保存到 dead_letter_dir
+func newTaskQueue(cfg *config.Config) *types.TaskQueue {
+	taskQueue := openTaskQueue(cfg)
+	taskQueue.SetDedup(time.Duration(cfg.Queue.DedupTTL)*time.Second, cfg.Queue.DedupSize)
+	taskQueue.SetRetryPolicy(types.RetryPolicy{
+		BaseDelay:     time.Duration(cfg.RetryBackoff.BaseDelay) * time.Second,
+		MaxDelay:      time.Duration(cfg.RetryBackoff.MaxDelay) * time.Second,
+		Multiplier:    cfg.RetryBackoff.Multiplier,
+		Jitter:        cfg.RetryBackoff.JitterFraction(),
+		MaxAttempts:   cfg.RetryMaxAttempts,
+		ClassAttempts: cfg.RetryBackoff.ClassAttempts,
+	})
+	dlq, err := deadletter.Open(cfg.DeadLetterDir)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	taskQueue.SetDeadLetter(func(rp *types.RetryProof, reason error) {
+		if err := dlq.Put(deadletter.NewEntry(rp, reason)); err != nil {
+			utils.LogWithTime("❌ 任务 %s 的证明保存到死信目录失败: %v", rp.Task.TaskID, err)
+			return
+		}
+		utils.LogWithTime("📮 任务 %s 的证明已保存到死信目录 %s，可用 dlq resubmit 重新提交", rp.Task.TaskID, dlq.Dir())
+	})
+
+	p := cfg.Queue.Priority
+	initial := make(map[string]time.Duration, len(p.ProgramCosts))
+	for programID, seconds := range p.ProgramCosts {
+		initial[programID] = time.Duration(seconds) * time.Second
+	}
+	costs := types.NewCostEstimator(initial, time.Duration(p.DefaultCost)*time.Second)
+	taskQueue.SetCostEstimator(costs)
+
+	programTTL := make(map[string]time.Duration, len(cfg.Queue.ProgramTTL))
+	for programID, seconds := range cfg.Queue.ProgramTTL {
+		programTTL[programID] = time.Duration(seconds) * time.Second
+	}
+	taskQueue.SetExpiry(types.ExpiryPolicy{
+		TTL:        time.Duration(cfg.Queue.TaskTTL) * time.Second,
+		ProgramTTL: programTTL,
+		OnDrop: func(task *types.Task, reason error) {
+			utils.LogWithTime("⌛ 任务 %s (节点 %s, 程序 %s, 创建于 %s) %v，不再计算",
+				task.TaskID, task.NodeID, task.ProgramID, task.CreatedAt.Format("15:04:05"), reason)
+		},
+	})
+	taskQueue.SetFairness(queueFairness(cfg))
+	if cfg.Queue.Scheduling == config.SCHEDULING_PRIORITY {
+		age, existing, cost := p.Weights()
+		taskQueue.SetScorer(types.NewScorer(types.PriorityWeights{
+			Age:      age,
+			Existing: existing,
+			Programs: p.ProgramBonus,
+			Cost:     cost,
+		}, costs))
+	}
+	return taskQueue
+}
+
+// queueFairness 按 queue.fairness 返回节点公平出队的权重，nil 表示不区分节点
+func queueFairness(cfg *config.Config) map[string]int {
+	switch cfg.Queue.Fairness {
+	case config.FAIRNESS_ROUND_ROBIN:
+		return map[string]int{}
+	case config.FAIRNESS_WEIGHTED:
+		return cfg.NodeWeights()
+	default:
+		return nil
+	}
+}
+
+// openTaskQueue 创建内存或磁盘任务队列
+func openTaskQueue(cfg *config.Config) *types.TaskQueue {
+	if cfg.Queue.Backend != config.QUEUE_DISK {
+		return types.NewTaskQueue(cfg.TaskQueueCapacity, cfg.RetryQueueCapacity)
+	}
+	taskQueue, recovered, err := types.OpenTaskQueue(cfg.TaskQueueCapacity, cfg.RetryQueueCapacity, types.WALOptions{
+		Dir:            cfg.Queue.Dir,
+		SyncInterval:   cfg.Queue.SyncInterval(),
+		CompactRecords: cfg.Queue.CompactRecords,
+		OnError: func(err error) {
+			utils.LogWithTime("⚠️ 写任务队列日志失败，任务只保存在内存中: %v", err)
+		},
+	})
+	if err != nil {
+		log.Fatalf("❌ 打开磁盘任务队列失败: %v", err)
+	}
+	utils.LogWithTime("💽 磁盘任务队列: %s (刷盘策略: %s)，恢复排队任务 %d 个、中断的计算任务 %d 个、待重新提交的证明 %d 个",
+		cfg.Queue.Dir, cfg.Queue.Fsync, recovered.Queued, recovered.InFlight, recovered.Retries)
+	return taskQueue
+}
+
+// newSigner 根据配置创建签名器，进程内签名时同时返回密钥库（用于热更新节点的 key_file），外部签名服务时为 nil
+func newSigner(cfg *config.Config) (api.Signer, *keystore.Store, error) {
+	switch cfg.Signer.Type {
+	case config.SIGNER_LOCAL:
+		keys, err := keystore.Open(cfg.KeyDir, os.Getenv(config.KEY_PASSPHRASE_ENV))
+		if err != nil {
+			return nil, nil, err
+		}
+		keys.SetKeyFiles(cfg.KeyFiles())
+		return api.NewKeyStoreSigner(keys), keys, nil
+	case config.SIGNER_SOCKET:
+		return api.NewSocketSigner(cfg.Signer.Socket), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("未知的签名方式: %q (可选: %s / %s)", cfg.Signer.Type, config.SIGNER_LOCAL, config.SIGNER_SOCKET)
+	}
+}
+
 func printHelp() {
 	fmt.Println("Nexus Prover CLI (进程隔离/普通模式)")
 	fmt.Println("")
 	fmt.Println("用法:")
 	fmt.Println("  ./nexus-prover [-c 配置文件] [-ps]")
+	fmt.Println("  ./nexus-prover <子命令> [参数]")
+	fmt.Println("")
+	fmt.Println("子命令:")
+	fmt.Println("  register-user [-uuid ID] [-wallet 地址]   # 注册用户，写回 user_id / wallet_address")
+	fmt.Println("  register-node [-user ID] [-n 数量]        # 注册CLI节点，新节点ID追加到 node_ids")
+	fmt.Println("  list-nodes [-wallet 地址] [-cli-only]     # 列出用户的全部节点")
+	fmt.Println("  keys list                                 # 列出 key_dir 中的节点密钥")
+	fmt.Println("  keys show -node ID                        # 显示节点公钥")
+	fmt.Println("  keys rotate -node ID                      # 为节点生成新密钥，旧密钥备份为 .bak")
+	fmt.Println("  keys export -node ID [-o 文件]            # 导出节点私钥（明文）")
+	fmt.Println("  config validate                           # 校验配置文件，列出全部错误")
+	fmt.Println("  config print [--effective]                # 输出生效的配置，--effective 同时列出每项的来源")
+	fmt.Println("  dlq list                                  # 列出放弃重试的证明（死信目录）")
+	fmt.Println("  dlq show -task ID                         # 显示死信详情")
+	fmt.Println("  dlq resubmit (-task ID | -all)            # 重新提交死信中的证明，成功后删除")
+	fmt.Println("  dlq purge (-task ID | -older 时长 | -all)  # 删除死信，如 -older 72h")
 	fmt.Println("")
 	fmt.Println("参数:")
 	fmt.Println("  -c, --config <文件>        # 指定配置文件 (默认: config.json)")
 	fmt.Println("  -ps, --process-isolation   # 启用进程隔离模式, 不加-ps参数则默认使用普通模式")
+	fmt.Println("  -watch <秒>                # 定期检查配置文件，修改后自动热更新（也可发送 SIGHUP 触发）")
+	fmt.Println("  -env <环境>                # 编排服务环境: beta / prod / local 或配置文件 profiles 中的自定义环境")
+	fmt.Println("  -<字段>=<值>               # 覆盖任意配置字段，如 -prover-workers=4、-rate-limit-window=30、-node-ids=1,2")
+	fmt.Println("")
+	fmt.Println("配置优先级（从低到高）: 默认值 < 配置文件 < 环境变量 < 命令行参数")
+	fmt.Println("  配置文件支持 JSON / YAML (.yaml/.yml) / TOML (.toml)")
+	fmt.Println("  环境变量: NEXUS_ + 字段路径大写，如 NEXUS_PROVER_WORKERS、NEXUS_RATE_LIMIT_WINDOW、NEXUS_NODE_IDS=1,2")
 	fmt.Println("  -h, --help                 # 显示帮助信息")
 	fmt.Println("  -v, --version              # 显示版本信息")
 	fmt.Println("")
@@ -220,13 +436,39 @@ func printHelp() {
 	fmt.Println("  ./nexus-prover -c myconfig.json -ps")
 	fmt.Println("配置文件格式:")
 	fmt.Println("  {")
-	fmt.Println("    \"node_ids\": [\"节点ID1\", \"节点ID2\"],")
+	fmt.Println("    \"node_ids\": [\"节点ID1\", \"节点ID2\",   # 节点ID，或带节点级覆盖的对象:")
+	fmt.Println("      {\"id\": \"节点ID3\", \"enabled\": true, \"weight\": 2, \"batch_size\": 5, \"task_fetch_interval\": 60,")
+	fmt.Println("       \"key_file\": \"keys/节点ID3.json\", \"telemetry_location\": \"unknown\"}],")
 	fmt.Println("    \"user_id\": \"用户ID\",                # 可以不填")
 	fmt.Println("    \"wallet_address\": \"钱包地址\",       # 可以不填")
 	fmt.Println("    \"request_delay\": 0,")
 	fmt.Println("    \"prover_workers\": 9,")
 	fmt.Println("    \"task_queue_capacity\": 1000,")
-	fmt.Println("    \"prover_submit_wait_second\": 10")
+	fmt.Println("    \"prover_submit_wait_second\": 10,")
+	fmt.Println("    \"existing_tasks_max_pages\": 10,       # 获取已分配任务时最多翻页数")
+	fmt.Println("    \"batch_size\": 3,                      # 每次获取新任务数量")
+	fmt.Println("    \"task_fetch_interval\": 180,           # 每个节点获取任务间隔（秒）")
+	fmt.Println("    \"retry_max_attempts\": 3,              # 提交失败最多重试次数")
+	fmt.Println("    \"retry_queue_capacity\": 100,          # 提交失败重试队列容量，已满时放弃新的失败证明（不阻塞）")
+	fmt.Println("    \"retry_backoff\": {\"base_delay\": 5, \"max_delay\": 300, \"multiplier\": 2, \"jitter\": 0.2,  # 指数退避（秒）")
+	fmt.Println("                      \"class_attempts\": {\"rate_limit\": 10, \"unauthorized\": 0}},  # 按错误类型覆盖重试次数")
+	fmt.Println("    \"stats_interval\": 60,                 # 周期统计间隔（秒）")
+	fmt.Println("    \"process_isolation\": {\"max_lifetime\": 300, \"max_restarts\": 3},")
+	fmt.Println("    \"queue\": {\"backend\": \"memory\",        # 任务队列: memory 或 disk（预写日志，重启后恢复任务和待提交的证明）")
+	fmt.Println("              \"fairness\": \"off\",          # 按节点公平出队: off / round_robin / weighted（证明时间按节点 weight 分配）")
+	fmt.Println("              \"high_water\": 90, \"low_water\": 50, # 队列深度百分比: 达到高水位暂停获取，回落到低水位恢复")
+	fmt.Println("              \"scheduling\": \"fifo\"},       # 出队顺序: fifo 或 priority（按任务年龄、是否已分配、程序、预估耗时打分）")
+	fmt.Println("    \"node_discovery\": \"off\",             # 节点自动发现: off / merge / replace")
+	fmt.Println("    \"node_discovery_interval\": 600,       # 节点列表刷新间隔（秒）")
+	fmt.Println("    \"telemetry_location\": \"unknown\",     # 遥测上报的地理位置")
+	fmt.Println("    \"key_dir\": \"keys\",                   # 节点密钥目录，口令通过环境变量 NEXUS_KEY_PASSPHRASE 提供")
+	fmt.Println("    \"dead_letter_dir\": \"dead_letter\",    # 放弃重试的证明保存目录，见 dlq 子命令")
+	fmt.Println("    \"signer\": {\"type\": \"local\"},         # 签名方式: local 或 socket（通过 nexus-signer 签名）")
+	fmt.Println("    \"rate_limit\": {\"global_requests\": 60, \"node_requests\": 10, \"window\": 60, \"backoff\": 60},")
+	fmt.Println("    \"environment\": \"beta\",               # 可选: beta / prod / local")
+	fmt.Println("    \"profiles\": {                          # 可选: 自定义或覆盖环境")
+	fmt.Println("      \"staging\": {\"base_url\": \"https://staging.example\", \"api_version\": \"v3\", \"request_timeout\": 30}")
+	fmt.Println("    }")
 	fmt.Println("  }")
 	fmt.Println("")
 }
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"nexus-prover/internal/config"
+	"nexus-prover/internal/utils"
+	pb "nexus-prover/proto"
+)
+
+// cmdRegisterUser 注册用户，并将 user_id / wallet_address 写回配置文件
+func cmdRegisterUser(args []string) {
+	cf := newCommandFlags("register-user")
+	userID := cf.fs.String("uuid", "", "用户UUID (默认: 配置文件 user_id，为空则自动生成)")
+	wallet := cf.fs.String("wallet", "", "钱包地址 (默认: 配置文件 wallet_address)")
+	cf.parse(args)
+	cfg := cf.load()
+
+	if *userID == "" {
+		*userID = cfg.UserID
+	}
+	if *userID == "" {
+		id, err := utils.NewUUID()
+		if err != nil {
+			log.Fatalf("❌ 生成用户UUID失败: %v", err)
+		}
+		*userID = id
+	}
+	if *wallet == "" {
+		*wallet = cfg.WalletAddress
+	}
+	if *wallet == "" {
+		log.Fatal("❌ 必须指定钱包地址 (-wallet 或配置文件 wallet_address)")
+	}
+
+	apiClient := cf.client(cfg)
+	ctx, stop := commandContext()
+	defer stop()
+	if err := apiClient.RegisterUser(ctx, *userID, *wallet); err != nil {
+		log.Fatalf("❌ 注册用户失败: %v", err)
+	}
+	fmt.Printf("✅ 用户注册成功: %s (钱包: %s)\n", *userID, *wallet)
+
+	if err := config.SetUser(*cf.configPath, *userID, *wallet); err != nil {
+		log.Fatalf("❌ 写回配置文件失败: %v", err)
+	}
+	fmt.Printf("📝 已写入配置文件: %s\n", *cf.configPath)
+}
+
+// cmdRegisterNode 为用户注册CLI节点，并将新节点ID追加到配置文件 node_ids
+func cmdRegisterNode(args []string) {
+	cf := newCommandFlags("register-node")
+	userID := cf.fs.String("user", "", "用户UUID (默认: 配置文件 user_id)")
+	count := cf.fs.Int("n", 1, "注册节点数量")
+	cf.parse(args)
+	cfg := cf.load()
+
+	if *userID == "" {
+		*userID = cfg.UserID
+	}
+	if *userID == "" {
+		log.Fatal("❌ 必须指定用户UUID (-user 或配置文件 user_id)，可先执行 register-user")
+	}
+	if *count <= 0 {
+		log.Fatal("❌ 注册节点数量必须大于0")
+	}
+
+	apiClient := cf.client(cfg)
+	ctx, stop := commandContext()
+	defer stop()
+	var nodeIDs []string
+	for i := 0; i < *count; i++ {
+		nodeID, err := apiClient.RegisterNode(ctx, *userID)
+		if err != nil {
+			log.Printf("❌ 注册第%d个节点失败: %v", i+1, err)
+			break
+		}
+		fmt.Printf("✅ 节点注册成功: %s\n", nodeID)
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	if len(nodeIDs) == 0 {
+		log.Fatal("❌ 没有注册成功的节点")
+	}
+
+	added, err := config.AddNodeIDs(*cf.configPath, nodeIDs...)
+	if err != nil {
+		log.Fatalf("❌ 写回配置文件失败: %v", err)
+	}
+	fmt.Printf("📝 已将 %d 个节点ID写入配置文件: %s\n", len(added), *cf.configPath)
+}
+
+// cmdListNodes 列出用户的全部节点
+func cmdListNodes(args []string) {
+	cf := newCommandFlags("list-nodes")
+	wallet := cf.fs.String("wallet", "", "钱包地址 (默认: 配置文件 wallet_address)")
+	cliOnly := cf.fs.Bool("cli-only", false, "只显示CLI节点")
+	cf.parse(args)
+	cfg := cf.load()
+
+	if *wallet == "" {
+		*wallet = cfg.WalletAddress
+	}
+	if *wallet == "" {
+		log.Fatal("❌ 必须指定钱包地址 (-wallet 或配置文件 wallet_address)")
+	}
+
+	apiClient := cf.client(cfg)
+	ctx, stop := commandContext()
+	defer stop()
+	nodes, err := apiClient.ListUserNodes(ctx, *wallet)
+	if err != nil {
+		log.Fatalf("❌ 获取节点列表失败: %v", err)
+	}
+
+	configured := make(map[string]bool, len(cfg.Nodes))
+	for _, id := range cfg.NodeIDs() {
+		configured[id] = true
+	}
+
+	shown := 0
+	for _, node := range nodes {
+		if *cliOnly && node.NodeType != pb.NodeType_CLI_PROVER {
+			continue
+		}
+		mark := ""
+		if configured[node.NodeId] {
+			mark = " (已配置)"
+		}
+		fmt.Printf("  %-16s %s%s\n", node.NodeId, node.NodeType, mark)
+		shown++
+	}
+	fmt.Printf("共 %d 个节点\n", shown)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"nexus-prover/internal/config"
+	"nexus-prover/internal/keystore"
+	"nexus-prover/internal/telemetry"
+	"nexus-prover/internal/utils"
+	"nexus-prover/internal/worker"
+	"nexus-prover/pkg/types"
+)
+
+// liveFields 可以热更新的配置字段（node_ids 包括节点级覆盖），其他字段变化时拒绝并保持原值，需要重启生效
+var liveFields = map[string]bool{
+	"node_ids":                  true,
+	"prover_workers":            true,
+	"request_delay":             true,
+	"prover_submit_wait_second": true,
+}
+
+// configReloader 收到 SIGHUP 或配置文件变化时重新加载配置，只应用可热更新的字段，
+// 任务队列、重试队列和节点的获取间隔状态都保留
+type configReloader struct {
+	opts      config.LoadOptions
+	nodes     *worker.NodeSet
+	pool      *worker.WorkerPool
+	settings  *worker.Settings
+	keys      *keystore.Store // 外部签名服务时为 nil
+	collector *telemetry.Collector
+	queue     *types.TaskQueue
+
+	mu      sync.Mutex
+	current *config.Config // 当前生效的配置（只含已应用的热更新字段）
+}
+
+// reload 重新加载配置并应用差异，配置不合法时整体拒绝
+func (r *configReloader) reload(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	utils.LogWithTime("🔄 %s，重新加载配置", reason)
+	next, err := config.Load(r.opts)
+	if err != nil {
+		utils.LogWithTime("❌ 重新加载配置失败，继续使用当前配置: %v", err)
+		return
+	}
+	if len(next.Nodes) == 0 && !next.DiscoveryEnabled() {
+		utils.LogWithTime("❌ 重新加载配置失败，继续使用当前配置: node_ids 数组不能为空")
+		return
+	}
+
+	changed := config.ChangedFields(r.current, next)
+	if len(changed) == 0 {
+		utils.LogWithTime("🔄 配置没有变化")
+		return
+	}
+	var rejected []string
+	applied := *r.current
+	for _, field := range changed {
+		if !liveFields[field] {
+			rejected = append(rejected, field)
+			continue
+		}
+		switch field {
+		case "node_ids":
+			applied.Nodes = next.Nodes
+			added, removed := r.nodes.SetConfigured(next.Nodes)
+			if r.keys != nil {
+				r.keys.SetKeyFiles(next.KeyFiles())
+			}
+			r.collector.SetNodeLocations(next.NodeLocations())
+			if r.current.Queue.Fairness == config.FAIRNESS_WEIGHTED {
+				r.queue.SetFairness(next.NodeWeights())
+			}
+			utils.LogWithTime("🔄 node_ids 已更新: 新增 %v, 移除 %v, 当前 %d 个节点", added, removed, r.nodes.Len())
+		case "prover_workers":
+			applied.ProverWorkers = next.ProverWorkers
+			started, stopped := r.pool.Resize(next.ProverWorkers)
+			utils.LogWithTime("🔄 prover_workers: %d => %d (启动 %d 个, 停止 %d 个，停止的worker完成当前任务后退出)",
+				r.current.ProverWorkers, next.ProverWorkers, started, stopped)
+		case "request_delay":
+			applied.RequestDelay = next.RequestDelay
+			utils.LogWithTime("🔄 request_delay: %d => %d 秒", r.current.RequestDelay, next.RequestDelay)
+		case "prover_submit_wait_second":
+			applied.ProverSubmitWaitSecond = next.ProverSubmitWaitSecond
+			utils.LogWithTime("🔄 prover_submit_wait_second: %d => %d 秒", r.current.ProverSubmitWaitSecond, next.ProverSubmitWaitSecond)
+		}
+	}
+	r.settings.Update(&applied)
+	r.current = &applied
+	if len(rejected) > 0 {
+		utils.LogWithTime("⚠️ 以下配置项不支持热更新，已忽略（保持原值，重启后生效）: %s", strings.Join(rejected, ", "))
+	}
+}
+
+// run 处理重新加载请求直到 ctx 取消；watchInterval > 0 时轮询配置文件修改时间
+func (r *configReloader) run(ctx context.Context, hup <-chan os.Signal, watchInterval time.Duration) {
+	var tick <-chan time.Time
+	if watchInterval > 0 && r.opts.Path != "" {
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+		utils.LogWithTime("👀 监视配置文件变化: %s (间隔: %s)", r.opts.Path, watchInterval)
+	}
+	lastMod := fileModTime(r.opts.Path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-hup:
+			lastMod = fileModTime(r.opts.Path)
+			r.reload("收到信号 " + sig.String())
+		case <-tick:
+			mod := fileModTime(r.opts.Path)
+			if mod.IsZero() || mod.Equal(lastMod) {
+				continue
+			}
+			lastMod = mod
+			r.reload("配置文件已修改")
+		}
+	}
+}
+
+// fileModTime 文件修改时间，文件不存在时为零值
+func fileModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"nexus-prover/internal/config"
+	"nexus-prover/internal/keystore"
+)
+
+func main() {
+	configPath := flag.String("c", "", "配置文件路径，读取 node_ids / key_dir / signer.socket (可选)")
+	socketPath := flag.String("socket", "", "Unix socket 路径 (默认: 配置文件 signer.socket 或 "+config.DEFAULT_SIGNER_SOCKET+")")
+	keyDir := flag.String("key-dir", "", "节点密钥目录 (默认: 配置文件 key_dir 或 "+config.DEFAULT_KEY_DIR+")")
+	allow := flag.String("allow", "", "允许签名的节点ID，逗号分隔 (默认: 配置文件 node_ids)")
+	showHelp := flag.Bool("h", false, "显示帮助信息")
+	flag.Parse()
+
+	if *showHelp {
+		printHelp()
+		return
+	}
+
+	cfg := &config.Config{KeyDir: config.DEFAULT_KEY_DIR}
+	cfg.Signer.Socket = config.DEFAULT_SIGNER_SOCKET
+	if *configPath != "" {
+		loaded, err := config.LoadConfig(*configPath)
+		if err != nil {
+			log.Fatalf("❌ 加载配置文件失败: %v", err)
+		}
+		cfg = loaded
+	}
+	if *socketPath == "" {
+		*socketPath = cfg.Signer.Socket
+	}
+	if *keyDir == "" {
+		*keyDir = cfg.KeyDir
+	}
+	allowlist := cfg.NodeIDs()
+	if *allow != "" {
+		allowlist = nil
+		for _, id := range strings.Split(*allow, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				allowlist = append(allowlist, id)
+			}
+		}
+	}
+	if len(allowlist) == 0 {
+		log.Fatal("❌ 节点白名单为空，请通过 -allow 或配置文件 node_ids 指定")
+	}
+
+	keys, err := keystore.Open(*keyDir, os.Getenv(config.KEY_PASSPHRASE_ENV))
+	if err != nil {
+		log.Fatalf("❌ 打开密钥目录失败: %v", err)
+	}
+	keys.SetKeyFiles(cfg.KeyFiles())
+	// 启动时加载白名单节点的密钥，口令错误时立即失败
+	for _, nodeID := range allowlist {
+		pub, err := keys.PublicKey(nodeID)
+		if err != nil {
+			log.Fatalf("❌ 加载节点密钥失败: %v", err)
+		}
+		log.Printf("🔑 节点 %s 公钥: %x", nodeID, pub)
+	}
+
+	listener, err := listenUnix(*socketPath)
+	if err != nil {
+		log.Fatalf("❌ 监听 %s 失败: %v", *socketPath, err)
+	}
+
+	server := &http.Server{
+		Handler:     NewServer(keys, allowlist).Handler(),
+		ReadTimeout: 10 * time.Second,
+	}
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-c
+		log.Printf("🛑 收到信号 %v，正在关闭...", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	log.Printf("🚀 签名服务已启动: %s (密钥目录: %s, 白名单 %d 个节点, 加密: %v)",
+		*socketPath, keys.Dir(), len(allowlist), keys.Encrypted())
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	log.Printf("👋 签名服务已退出")
+}
+
+// listenUnix 监听 Unix socket，只允许当前用户访问；清理上次异常退出残留的 socket 文件。
+// 通过 <socket>.lock 文件锁判断 socket 是否属于正在运行的签名服务，
+// 持有锁期间删除残留文件和重新监听不会与其他签名服务竞争
+func listenUnix(path string) (net.Listener, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		lock.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, fmt.Errorf("socket 正在被其他签名服务使用")
+		}
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		lock.Close()
+		return nil, err
+	}
+	// socket 文件创建时即为 0600，不存在可被其他用户连接的窗口
+	oldMask := syscall.Umask(0177)
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(oldMask)
+	if err != nil {
+		lock.Close()
+		return nil, err
+	}
+	return &lockedListener{Listener: listener, lock: lock}, nil
+}
+
+// lockedListener 关闭监听时释放 socket 文件锁
+type lockedListener struct {
+	net.Listener
+	lock *os.File
+}
+
+func (l *lockedListener) Close() error {
+	err := l.Listener.Close()
+	l.lock.Close()
+	return err
+}
+
+func printHelp() {
+	fmt.Println("Nexus 签名服务: 保存节点私钥，通过 Unix socket 为 nexus-prover 签名")
+	fmt.Println("")
+	fmt.Println("用法:")
+	fmt.Println("  ./nexus-signer -c config.json")
+	fmt.Println("  ./nexus-signer -socket /run/nexus/signer.sock -key-dir /etc/nexus/keys -allow 123456,234567")
+	fmt.Println("")
+	fmt.Println("参数:")
+	fmt.Println("  -c <文件>          # 配置文件，读取 node_ids(白名单) / key_dir / signer.socket")
+	fmt.Println("  -socket <路径>     # Unix socket 路径，权限为 0600")
+	fmt.Println("  -key-dir <目录>    # 节点密钥目录，口令通过环境变量 NEXUS_KEY_PASSPHRASE 提供")
+	fmt.Println("  -allow <ID列表>    # 允许签名的节点ID，逗号分隔，优先于配置文件 node_ids")
+	fmt.Println("  -h                 # 显示帮助信息")
+	fmt.Println("")
+	fmt.Println("nexus-prover 配置:")
+	fmt.Println("  \"signer\": {\"type\": \"socket\", \"socket\": \"/run/nexus/signer.sock\"}")
+}
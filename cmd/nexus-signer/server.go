@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"nexus-prover/internal/api"
+	"nexus-prover/internal/keystore"
+)
+
+// proofHashLen 签名数据末尾 proof_hash 的长度（sha256 hex）
+const proofHashLen = 64
+
+// maxRequestBytes 请求体上限，签名数据只有 task_id + proof_hash
+const maxRequestBytes = 4096
+
+// Server 签名服务：只为白名单中的节点提供公钥和签名
+type Server struct {
+	keys    *keystore.Store
+	allowed map[string]bool
+
+	mu     sync.Mutex
+	signed map[string]int64 // 节点ID -> 签名次数
+}
+
+// NewServer 创建签名服务
+func NewServer(keys *keystore.Store, allowlist []string) *Server {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, id := range allowlist {
+		allowed[id] = true
+	}
+	return &Server{
+		keys:    keys,
+		allowed: allowed,
+		signed:  make(map[string]int64),
+	}
+}
+
+// Handler 注册路由
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(api.SIGNER_PATH_PUBLIC_KEY, s.handlePublicKey)
+	mux.HandleFunc(api.SIGNER_PATH_SIGN, s.handleSign)
+	return mux
+}
+
+func (s *Server) handlePublicKey(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.readRequest(w, r)
+	if !ok {
+		return
+	}
+	pub, err := s.keys.PublicKey(req.NodeID)
+	if err != nil {
+		log.Printf("❌ 节点 %s 获取公钥失败: %v", req.NodeID, err)
+		writeResponse(w, http.StatusInternalServerError, &api.SignerResponse{Error: err.Error()})
+		return
+	}
+	writeResponse(w, http.StatusOK, &api.SignerResponse{PublicKey: pub})
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.readRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := checkMessage(req.Message); err != nil {
+		log.Printf("🚫 拒绝节点 %s 的签名请求: %v", req.NodeID, err)
+		writeResponse(w, http.StatusBadRequest, &api.SignerResponse{Error: err.Error()})
+		return
+	}
+	priv, err := s.keys.Get(req.NodeID)
+	if err != nil {
+		log.Printf("❌ 节点 %s 加载密钥失败: %v", req.NodeID, err)
+		writeResponse(w, http.StatusInternalServerError, &api.SignerResponse{Error: err.Error()})
+		return
+	}
+	signature := ed25519.Sign(priv, req.Message)
+
+	s.mu.Lock()
+	s.signed[req.NodeID]++
+	count := s.signed[req.NodeID]
+	s.mu.Unlock()
+	log.Printf("✍️ 节点 %s 签名任务 %s (累计 %d 次)", req.NodeID, req.Message[:len(req.Message)-proofHashLen], count)
+
+	writeResponse(w, http.StatusOK, &api.SignerResponse{Signature: signature})
+}
+
+// readRequest 解析请求并检查节点白名单
+func (s *Server) readRequest(w http.ResponseWriter, r *http.Request) (*api.SignerRequest, bool) {
+	if r.Method != http.MethodPost {
+		writeResponse(w, http.StatusMethodNotAllowed, &api.SignerResponse{Error: "method not allowed"})
+		return nil, false
+	}
+	var req api.SignerRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeResponse(w, http.StatusBadRequest, &api.SignerResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return nil, false
+	}
+	if !s.allowed[req.NodeID] {
+		log.Printf("🚫 拒绝不在白名单中的节点: %q", req.NodeID)
+		writeResponse(w, http.StatusForbidden, &api.SignerResponse{Error: fmt.Sprintf("node %q is not allowed", req.NodeID)})
+		return nil, false
+	}
+	return &req, true
+}
+
+// checkMessage 只签名 task_id + proof_hash 格式的数据，避免签名服务被当作任意数据的签名工具
+func checkMessage(msg []byte) error {
+	if len(msg) <= proofHashLen {
+		return fmt.Errorf("message too short: %d bytes", len(msg))
+	}
+	for _, c := range msg[len(msg)-proofHashLen:] {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return fmt.Errorf("message must end with a hex sha256 proof hash")
+		}
+	}
+	return nil
+}
+
+func writeResponse(w http.ResponseWriter, status int, resp *api.SignerResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nexus-prover/internal/api"
+	"nexus-prover/internal/keystore"
+)
+
+// socketDir Unix socket 路径有长度限制，不使用 t.TempDir 的长路径
+func socketDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// TestSocketSignerRoundTrip 测试 SocketSigner 通过 Unix socket 调用签名服务
+func TestSocketSignerRoundTrip(t *testing.T) {
+	keys, err := keystore.Open(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(socketDir(t), "signer.sock")
+	listener, err := listenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: NewServer(keys, []string{"1001"}).Handler()}
+	go server.Serve(listener)
+	defer server.Close()
+
+	ctx := context.Background()
+	signer := api.NewSocketSigner(path)
+	pub, err := signer.PublicKey(ctx, "1001")
+	if err != nil {
+		t.Fatalf("获取公钥失败: %v", err)
+	}
+	msg := []byte("task-1" + strings.Repeat("ab", proofHashLen/2))
+	sig, err := signer.Sign(ctx, "1001", msg)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !ed25519.Verify(pub, msg, sig) {
+		t.Error("签名无法用签名服务返回的公钥验证")
+	}
+
+	tests := []struct {
+		name   string
+		nodeID string
+		msg    []byte
+		status int
+	}{
+		{"不在白名单", "2002", msg, http.StatusForbidden},
+		{"缺少proof_hash", "1001", []byte("task-1"), http.StatusBadRequest},
+		{"proof_hash非hex", "1001", []byte("task-1" + strings.Repeat("zz", proofHashLen/2)), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signer.Sign(ctx, tt.nodeID, tt.msg)
+			var serr *api.SignerError
+			if !errors.As(err, &serr) || serr.StatusCode != tt.status {
+				t.Errorf("错误 = %v, 期望 HTTP %d", err, tt.status)
+			}
+		})
+	}
+}
+
+// TestListenUnix 测试 socket 权限、重复启动和残留 socket 文件的清理
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(socketDir(t), "signer.sock")
+	listener, err := listenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("socket 权限 = %o, 期望 600", mode)
+	}
+	if _, err := listenUnix(path); err == nil {
+		t.Error("socket 正在使用时应拒绝启动")
+	}
+
+	listener.Close()
+	// 模拟异常退出残留的 socket 文件
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	listener, err = listenUnix(path)
+	if err != nil {
+		t.Fatalf("应清理残留的 socket 文件: %v", err)
+	}
+	listener.Close()
+}
@@ -2,4 +2,9 @@ module nexus-prover
 
 go 1.23.2
 
-require google.golang.org/protobuf v1.36.6
+require (
+	github.com/BurntSushi/toml v1.5.0
+	golang.org/x/crypto v0.31.0
+	google.golang.org/protobuf v1.36.6
+	gopkg.in/yaml.v3 v3.0.1
+)
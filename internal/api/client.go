@@ -2,14 +2,18 @@ package api
 
 import (
 	"bytes"
-	"crypto/ed25519"
+	"context"
 	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strings"
 	"time"
 
+	"nexus-prover/internal/config"
+	"nexus-prover/internal/telemetry"
 	"nexus-prover/pkg/types"
 	pb "nexus-prover/proto"
 
@@ -18,81 +22,108 @@ import (
 
 // Client API客户端
 type Client struct {
-	httpClient *http.Client
-	tasksURL   string
-	submitURL  string
+	httpClient   *http.Client
+	profile      config.Profile
+	tasksURL     string
+	submitURL    string
+	maxTaskPages int                  // 获取已分配任务时最多翻页数
+	max404s      int                  // 批量获取新任务时连续无任务次数上限
+	telemetry    *telemetry.Collector // 节点遥测数据，为空时只上报默认地理位置
+	limiter      *RateLimiter         // 客户端限流，为空时不限流
+	signer       Signer               // 节点签名器，获取新任务和提交证明时按节点ID取用
 }
 
-// NewClient 创建新的API客户端
-func NewClient() *Client {
-	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second, // 30秒超时
-			Transport: &http.Transport{
-				MaxIdleConns:        100,              // 最大空闲连接数
-				MaxIdleConnsPerHost: 10,               // 每个主机的最大空闲连接数
-				IdleConnTimeout:     90 * time.Second, // 空闲连接超时时间
-				TLSHandshakeTimeout: 10 * time.Second, // TLS握手超时时间
-			},
-		},
-		tasksURL:  "https://beta.orchestrator.nexus.xyz/v3/tasks",
-		submitURL: "https://beta.orchestrator.nexus.xyz/v3/tasks/submit",
-	}
-}
-
-// FetchTask 获取任务（protobuf POST）
-func (c *Client) FetchTask(nodeID string, pub ed25519.PublicKey) (*pb.GetProofTaskResponse, error) {
-	req := &pb.GetProofTaskRequest{
-		NodeId:           nodeID,
-		NodeType:         pb.NodeType_CLI_PROVER,
-		Ed25519PublicKey: []byte(pub),
-	}
-
-	data, err := proto.Marshal(req)
+// NewClient 根据配置中选中的环境创建API客户端
+func NewClient(cfg *config.Config) (*Client, error) {
+	profile, err := cfg.ActiveProfile()
 	if err != nil {
 		return nil, err
 	}
-
-	resp, err := c.httpClient.Post(c.tasksURL, "application/octet-stream", bytes.NewReader(data))
+	c, err := NewClientWithProfile(profile)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	c.maxTaskPages = cfg.ExistingTasksMaxPages
+	c.max404s = cfg.MaxConsecutive404s
+	c.limiter = NewRateLimiter(cfg.RateLimit)
+	return c, nil
+}
 
-	respData, err := ioutil.ReadAll(resp.Body)
+// NewClientWithProfile 使用指定环境配置创建API客户端
+func NewClientWithProfile(profile config.Profile) (*Client, error) {
+	tlsConfig, err := newTLSConfig(profile)
 	if err != nil {
 		return nil, err
 	}
+	return &Client{
+		httpClient: &http.Client{
+			// 不设置全局超时，由 roundTrip 按请求类型设置context截止时间
+			Transport: &http.Transport{
+				MaxIdleConns:        100,                                                      // 最大空闲连接数
+				MaxIdleConnsPerHost: 10,                                                       // 每个主机的最大空闲连接数
+				IdleConnTimeout:     time.Duration(profile.IdleConnTimeout) * time.Second,     // 空闲连接超时时间
+				TLSHandshakeTimeout: time.Duration(profile.TLSHandshakeTimeout) * time.Second, // TLS握手超时时间
+				TLSClientConfig:     tlsConfig,
+			},
+		},
+		profile:      profile,
+		tasksURL:     profile.URL("tasks"),
+		submitURL:    profile.URL("tasks", "submit"),
+		maxTaskPages: config.DEFAULT_EXISTING_TASKS_MAX_PAGES,
+		max404s:      config.DEFAULT_MAX_CONSECUTIVE_404S,
+	}, nil
+}
 
-	if resp.StatusCode == 429 {
-		// 速率限制，等待更长时间
-		return nil, fmt.Errorf("rate limit exceeded: %s", string(respData))
-	}
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("fetchTask failed: %s", string(respData))
+// newTLSConfig 根据环境配置构造TLS配置
+func newTLSConfig(profile config.Profile) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: profile.InsecureSkipVerify}
+	if profile.CACertFile != "" {
+		pem, err := ioutil.ReadFile(profile.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书失败: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA证书格式无效: %s", profile.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
 	}
+	return tlsConfig, nil
+}
 
-	var proofResp pb.GetProofTaskResponse
-	if err := proto.Unmarshal(respData, &proofResp); err != nil {
-		return nil, err
+// Profile 当前使用的环境配置
+func (c *Client) Profile() config.Profile {
+	return c.profile
+}
+
+// timeoutFor 单次请求超时：提交证明使用 submit_timeout，其余使用 request_timeout
+func (c *Client) timeoutFor(op string) time.Duration {
+	if op == opSubmitProof {
+		return time.Duration(c.profile.SubmitTimeout) * time.Second
 	}
-	return &proofResp, nil
+	return time.Duration(c.profile.RequestTimeout) * time.Second
 }
 
-// GetExistingTasks 获取已分配任务（优先）
-func (c *Client) GetExistingTasks(nodeID string) ([]*pb.GetProofTaskResponse, error) {
-	// 构造 protobuf body
-	req := &pb.GetTasksRequest{
-		NodeId:     nodeID,
-		NextCursor: "",
+// roundTrip 发送protobuf请求并读取响应体，非200响应转换为类型化错误，msg 为 nil 时不带请求体；
+// 发送前按 nodeID 等待限流令牌，nodeID 为空时只受全局限流
+func (c *Client) roundTrip(ctx context.Context, op, nodeID, method, url string, msg proto.Message) ([]byte, error) {
+	var data []byte
+	if msg != nil {
+		var err error
+		if data, err = proto.Marshal(msg); err != nil {
+			return nil, err
+		}
 	}
-	data, err := proto.Marshal(req)
-	if err != nil {
+
+	if err := c.limiter.Wait(ctx, nodeID); err != nil {
 		return nil, err
 	}
 
-	// 构造 GET 请求，body 为 protobuf
-	httpReq, err := http.NewRequest("GET", c.tasksURL, bytes.NewReader(data))
+	// 每次请求的超时由环境配置决定，父context取消时立即中断
+	ctx, cancel := context.WithTimeout(ctx, c.timeoutFor(op))
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
@@ -109,79 +140,125 @@ func (c *Client) GetExistingTasks(nodeID string) ([]*pb.GetProofTaskResponse, er
 		return nil, err
 	}
 
-	if resp.StatusCode == 429 {
-		return nil, fmt.Errorf("rate limit exceeded: %s", string(respData))
+	if resp.StatusCode != http.StatusOK {
+		err := newStatusError(op, resp, respData)
+		var rateLimited *RateLimitError
+		if errors.As(err, &rateLimited) {
+			c.limiter.OnRateLimited(nodeID, rateLimited.RetryAfter)
+		}
+		return nil, err
 	}
+	c.limiter.OnSuccess(nodeID)
+	return respData, nil
+}
 
-	if resp.StatusCode == 404 {
-		return nil, fmt.Errorf("no existing tasks found")
+// decode 解析protobuf响应，失败时返回 DecodeError
+func decode(op string, data []byte, msg proto.Message) error {
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return &DecodeError{HTTPError: HTTPError{Op: op, StatusCode: http.StatusOK, Body: data}, Err: err}
 	}
+	return nil
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("get existing tasks failed: %s", string(respData))
-	}
+// FetchTask 获取任务（protobuf POST）
+func (c *Client) FetchTask(ctx context.Context, nodeID string) (*pb.GetProofTaskResponse, error) {
+	return c.GetNewTask(ctx, nodeID)
+}
 
-	// 解析响应
-	var tasksResp pb.GetTasksResponse
-	if err := proto.Unmarshal(respData, &tasksResp); err != nil {
-		return nil, err
-	}
+// GetExistingTasks 获取已分配任务（优先），按 next_cursor 翻页，最多读取 maxTaskPages 页
+func (c *Client) GetExistingTasks(ctx context.Context, nodeID string) ([]*types.Task, error) {
+	var tasks []*types.Task
+	cursor := ""
+	for page := 0; page < c.maxTaskPages; page++ {
+		// 构造 protobuf body
+		req := &pb.GetTasksRequest{
+			NodeId:     nodeID,
+			NextCursor: cursor,
+		}
 
-	if len(tasksResp.Tasks) == 0 {
-		return nil, fmt.Errorf("no existing tasks found")
-	}
+		// GET 请求，body 为 protobuf
+		respData, err := c.roundTrip(ctx, opGetExistingTasks, nodeID, http.MethodGet, c.tasksURL, req)
+		if err != nil {
+			if len(tasks) > 0 {
+				// 已取到部分页，先返回，剩余页下个周期再取
+				return tasks, nil
+			}
+			return nil, err
+		}
 
-	// 转换为GetProofTaskResponse格式
-	var tasks []*pb.GetProofTaskResponse
-	for _, task := range tasksResp.Tasks {
-		tasks = append(tasks, &pb.GetProofTaskResponse{
-			TaskId:       task.TaskId,
-			ProgramId:    task.ProgramId,
-			PublicInputs: task.PublicInputs,
-		})
+		// 解析响应
+		var tasksResp pb.GetTasksResponse
+		if err := decode(opGetExistingTasks, respData, &tasksResp); err != nil {
+			if len(tasks) > 0 {
+				// 同网络错误，先返回已取到的页
+				return tasks, nil
+			}
+			return nil, err
+		}
+
+		fetchedAt := time.Now()
+		for _, task := range tasksResp.Tasks {
+			tasks = append(tasks, newTaskFromProto(nodeID, task, fetchedAt))
+		}
+
+		if tasksResp.NextCursor == "" || tasksResp.NextCursor == cursor {
+			break
+		}
+		cursor = tasksResp.NextCursor
 	}
 
+	if len(tasks) == 0 {
+		return nil, &NoTaskError{HTTPError{Op: opGetExistingTasks, StatusCode: http.StatusOK}}
+	}
 	return tasks, nil
 }
 
-// GetNewTask 获取新任务
-func (c *Client) GetNewTask(nodeID string, pub ed25519.PublicKey) (*pb.GetProofTaskResponse, error) {
-	req := &pb.GetProofTaskRequest{
-		NodeId:           nodeID,
-		NodeType:         pb.NodeType_CLI_PROVER,
-		Ed25519PublicKey: []byte(pub),
+// newTaskFromProto 转换已分配任务，保留服务端创建时间，缺失时使用获取时间
+func newTaskFromProto(nodeID string, task *pb.Task, fetchedAt time.Time) *types.Task {
+	createdAt := fetchedAt
+	if task.CreatedAt != nil && task.CreatedAt.IsValid() {
+		createdAt = task.CreatedAt.AsTime()
 	}
-
-	data, err := proto.Marshal(req)
-	if err != nil {
-		return nil, err
+	return &types.Task{
+		TaskID:       task.TaskId,
+		ProgramID:    task.ProgramId,
+		PublicInputs: task.PublicInputs,
+		NodeID:       nodeID,
+		CreatedAt:    createdAt,
+		Existing:     true,
 	}
+}
 
-	resp, err := c.httpClient.Post(c.tasksURL, "application/octet-stream", bytes.NewReader(data))
-	if err != nil {
-		return nil, err
+// newTaskFromResponse 转换新获取的任务，服务端未返回创建时间，使用获取时间
+func newTaskFromResponse(nodeID string, resp *pb.GetProofTaskResponse, fetchedAt time.Time) *types.Task {
+	return &types.Task{
+		TaskID:       resp.TaskId,
+		ProgramID:    resp.ProgramId,
+		PublicInputs: resp.PublicInputs,
+		NodeID:       nodeID,
+		CreatedAt:    fetchedAt,
 	}
-	defer resp.Body.Close()
+}
 
-	respData, err := ioutil.ReadAll(resp.Body)
+// GetNewTask 获取新任务
+func (c *Client) GetNewTask(ctx context.Context, nodeID string) (*pb.GetProofTaskResponse, error) {
+	pub, err := c.publicKey(ctx, nodeID)
 	if err != nil {
 		return nil, err
 	}
-
-	if resp.StatusCode == 429 {
-		return nil, fmt.Errorf("rate limit exceeded: %s", string(respData))
-	}
-
-	if resp.StatusCode == 404 {
-		return nil, fmt.Errorf("no task available")
+	req := &pb.GetProofTaskRequest{
+		NodeId:           nodeID,
+		NodeType:         pb.NodeType_CLI_PROVER,
+		Ed25519PublicKey: []byte(pub),
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("get new task failed: %s", string(respData))
+	respData, err := c.roundTrip(ctx, opGetNewTask, nodeID, http.MethodPost, c.tasksURL, req)
+	if err != nil {
+		return nil, err
 	}
 
 	var proofResp pb.GetProofTaskResponse
-	if err := proto.Unmarshal(respData, &proofResp); err != nil {
+	if err := decode(opGetNewTask, respData, &proofResp); err != nil {
 		return nil, err
 	}
 
@@ -189,49 +266,53 @@ func (c *Client) GetNewTask(nodeID string, pub ed25519.PublicKey) (*pb.GetProofT
 }
 
 // FetchTaskSmart 智能任务获取 - 优先获取已分配任务
-func (c *Client) FetchTaskSmart(nodeID string, pub ed25519.PublicKey, state *types.TaskFetchState) (*pb.GetProofTaskResponse, error) {
+func (c *Client) FetchTaskSmart(ctx context.Context, nodeID string, state *types.TaskFetchState) (*types.Task, error) {
 	// 首先尝试获取已分配任务
-	existingTasks, err := c.GetExistingTasks(nodeID)
+	existingTasks, err := c.GetExistingTasks(ctx, nodeID)
 	if err != nil {
-		if strings.Contains(err.Error(), "no existing tasks found") ||
-			strings.Contains(err.Error(), "404") {
-			// 继续尝试获取新任务
-		} else if strings.Contains(err.Error(), "rate limit exceeded") {
+		var rateLimited *RateLimitError
+		if errors.As(err, &rateLimited) {
 			return nil, err
-		} else {
-			// 继续尝试获取新任务
 		}
-	} else {
+		// 无已分配任务或其他错误，继续尝试获取新任务
+	} else if len(existingTasks) > 0 {
 		// 成功获取已分配任务
-		if len(existingTasks) > 0 {
-			return existingTasks[0], nil // 返回第一个任务
-		}
+		return existingTasks[0], nil // 返回第一个任务
 	}
 
 	// 如果没有已分配任务，获取新任务
-	return c.GetNewTask(nodeID, pub)
+	resp, err := c.GetNewTask(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return newTaskFromResponse(nodeID, resp, time.Now()), nil
 }
 
 // FetchTaskBatch 批量获取任务
-func (c *Client) FetchTaskBatch(nodeID string, pub ed25519.PublicKey, batchSize int, state *types.TaskFetchState) ([]*pb.GetProofTaskResponse, error) {
-	var tasks []*pb.GetProofTaskResponse
+func (c *Client) FetchTaskBatch(ctx context.Context, nodeID string, batchSize int, state *types.TaskFetchState) ([]*types.Task, error) {
+	var tasks []*types.Task
 
 	// 首先尝试获取已分配任务
-	existingTasks, err := c.GetExistingTasks(nodeID)
+	existingTasks, err := c.GetExistingTasks(ctx, nodeID)
 	if err == nil && len(existingTasks) > 0 {
 		return existingTasks, nil
 	}
 
 	// 批量获取新任务
-	for i := 0; i < batchSize; i++ {
-		task, err := c.GetNewTask(nodeID, pub)
+	for i := 0; i < batchSize && ctx.Err() == nil; i++ {
+		task, err := c.GetNewTask(ctx, nodeID)
 		if err != nil {
-			if strings.Contains(err.Error(), "rate limit exceeded") {
+			var rateLimited *RateLimitError
+			var noTask *NoTaskError
+			if errors.As(err, &rateLimited) {
+				if len(tasks) == 0 {
+					return nil, err
+				}
 				break
 			}
-			if strings.Contains(err.Error(), "no task available") {
+			if errors.As(err, &noTask) {
 				state.Consecutive404s++
-				if state.Consecutive404s >= 5 {
+				if state.Consecutive404s >= c.max404s {
 					break
 				}
 				continue
@@ -240,7 +321,7 @@ func (c *Client) FetchTaskBatch(nodeID string, pub ed25519.PublicKey, batchSize
 		}
 
 		// 成功获取任务
-		tasks = append(tasks, task)
+		tasks = append(tasks, newTaskFromResponse(nodeID, task, time.Now()))
 		state.Consecutive404s = 0 // 重置404计数器
 	}
 
@@ -248,15 +329,22 @@ func (c *Client) FetchTaskBatch(nodeID string, pub ed25519.PublicKey, batchSize
 }
 
 // SubmitProof 提交证明（protobuf POST）
-func (c *Client) SubmitProof(task *types.Task, proof []byte, priv ed25519.PrivateKey) error {
+func (c *Client) SubmitProof(ctx context.Context, task *types.Task, proof []byte) error {
 	// 计算证明哈希
 	proofHash := fmt.Sprintf("%x", sha256.Sum256(proof))
 
 	// 构造签名数据: task_id + proof_hash
 	signData := []byte(task.TaskID + proofHash)
 
-	// 使用私钥签名
-	signature := ed25519.Sign(priv, signData)
+	// 使用任务所属节点的密钥签名
+	pub, err := c.publicKey(ctx, task.NodeID)
+	if err != nil {
+		return err
+	}
+	signature, err := c.sign(ctx, task.NodeID, signData)
+	if err != nil {
+		return err
+	}
 
 	// 构造完整的 SubmitProofRequest
 	req := &pb.SubmitProofRequest{
@@ -264,29 +352,17 @@ func (c *Client) SubmitProof(task *types.Task, proof []byte, priv ed25519.Privat
 		NodeType:         pb.NodeType_CLI_PROVER,
 		ProofHash:        proofHash,
 		Proof:            proof,
-		Ed25519PublicKey: priv.Public().(ed25519.PublicKey),
+		Ed25519PublicKey: pub,
 		Signature:        signature,
-		// 添加节点遥测数据（可选）
-		NodeTelemetry: &pb.NodeTelemetry{
-			Location: &[]string{"unknown"}[0],
-		},
-	}
-
-	data, err := proto.Marshal(req)
-	if err != nil {
-		return err
-	}
-
-	resp, err := c.httpClient.Post(c.submitURL, "application/octet-stream", bytes.NewReader(data))
-	if err != nil {
-		return err
+		// 节点遥测数据
+		NodeTelemetry: c.nodeTelemetry(task),
 	}
-	defer resp.Body.Close()
 
-	respData, _ := ioutil.ReadAll(resp.Body)
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("submitProof failed: %s", string(respData))
-	}
+	_, err = c.roundTrip(ctx, opSubmitProof, task.NodeID, http.MethodPost, c.submitURL, req)
+	return err
+}
 
-	return nil
+// RateLimiter 客户端使用的限流器，未启用时为 nil
+func (c *Client) RateLimiter() *RateLimiter {
+	return c.limiter
 }
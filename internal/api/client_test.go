@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"nexus-prover/internal/config"
+	pb "nexus-prover/proto"
+)
+
+// TestGetExistingTasksPages 测试翻页读取已分配任务，后续页失败时返回已取到的页
+func TestGetExistingTasksPages(t *testing.T) {
+	page := func(cursor string, ids ...string) []byte {
+		resp := &pb.GetTasksResponse{NextCursor: cursor}
+		for _, id := range ids {
+			resp.Tasks = append(resp.Tasks, &pb.Task{TaskId: id, ProgramId: "fib_input"})
+		}
+		data, _ := proto.Marshal(resp)
+		return data
+	}
+	garbage := []byte{0xff, 0xff, 0xff}
+	type response struct {
+		status int
+		body   []byte
+	}
+	tests := []struct {
+		name      string
+		pages     map[string]response // 请求的 next_cursor => 响应
+		wantIDs   []string
+		wantErr   interface{}
+		wantCalls int
+	}{
+		{
+			name: "全部页",
+			pages: map[string]response{
+				"":   {http.StatusOK, page("c1", "t1", "t2")},
+				"c1": {http.StatusOK, page("", "t3")},
+			},
+			wantIDs:   []string{"t1", "t2", "t3"},
+			wantCalls: 2,
+		},
+		{
+			name: "第2页无法解析",
+			pages: map[string]response{
+				"":   {http.StatusOK, page("c1", "t1", "t2")},
+				"c1": {http.StatusOK, garbage},
+			},
+			wantIDs:   []string{"t1", "t2"},
+			wantCalls: 2,
+		},
+		{
+			name: "第2页服务端错误",
+			pages: map[string]response{
+				"":   {http.StatusOK, page("c1", "t1")},
+				"c1": {http.StatusBadGateway, []byte("bad gateway")},
+			},
+			wantIDs:   []string{"t1"},
+			wantCalls: 2,
+		},
+		{
+			name: "第1页无法解析",
+			pages: map[string]response{
+				"": {http.StatusOK, garbage},
+			},
+			wantErr:   new(*DecodeError),
+			wantCalls: 1,
+		},
+		{
+			name: "第1页为空",
+			pages: map[string]response{
+				"": {http.StatusOK, page("")},
+			},
+			wantErr:   new(*NoTaskError),
+			wantCalls: 1,
+		},
+		{
+			name: "超过页数上限",
+			pages: map[string]response{
+				"":   {http.StatusOK, page("c1", "t1")},
+				"c1": {http.StatusOK, page("c2", "t2")},
+				"c2": {http.StatusOK, page("c3", "t3")},
+				"c3": {http.StatusOK, page("", "t4")},
+			},
+			wantIDs:   []string{"t1", "t2", "t3"},
+			wantCalls: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				body, _ := io.ReadAll(r.Body)
+				var req pb.GetTasksRequest
+				if err := proto.Unmarshal(body, &req); err != nil || req.NodeId != "1001" {
+					t.Errorf("请求 = %v, %v", &req, err)
+				}
+				resp := tt.pages[req.NextCursor]
+				w.WriteHeader(resp.status)
+				w.Write(resp.body)
+			}))
+			defer server.Close()
+
+			client, err := NewClientWithProfile(config.Profile{BaseURL: server.URL, APIVersion: "v3", RequestTimeout: 5})
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.maxTaskPages = 3
+
+			tasks, err := client.GetExistingTasks(context.Background(), "1001")
+			if calls != tt.wantCalls {
+				t.Errorf("请求 %d 次, 期望 %d 次", calls, tt.wantCalls)
+			}
+			if tt.wantErr != nil {
+				if !errors.As(err, tt.wantErr) {
+					t.Errorf("错误 = %v, 期望 %T", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("错误 = %v", err)
+			}
+			if len(tasks) != len(tt.wantIDs) {
+				t.Fatalf("任务数 = %d, 期望 %v", len(tasks), tt.wantIDs)
+			}
+			for i, task := range tasks {
+				if task.TaskID != tt.wantIDs[i] || task.NodeID != "1001" || !task.Existing {
+					t.Errorf("第 %d 个任务 = %+v, 期望 %s", i, task, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
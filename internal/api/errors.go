@@ -0,0 +1,134 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 请求操作名称，用于错误信息和错误分类
+const (
+	opGetExistingTasks = "get existing tasks"
+	opGetNewTask       = "get new task"
+	opSubmitProof      = "submit proof"
+	opRegisterUser     = "register user"
+	opRegisterNode     = "register node"
+	opGetUser          = "get user"
+)
+
+// HTTPError 编排服务返回的非200响应，携带HTTP状态码和原始响应体
+type HTTPError struct {
+	Op         string // 请求操作
+	StatusCode int    // HTTP状态码
+	Body       []byte // 原始响应体
+}
+
+func (e *HTTPError) Error() string {
+	body := strings.TrimSpace(string(bodyPreview(e.Body)))
+	if body == "" {
+		return fmt.Sprintf("%s failed: HTTP %d", e.Op, e.StatusCode)
+	}
+	return fmt.Sprintf("%s failed: HTTP %d: %s", e.Op, e.StatusCode, body)
+}
+
+// HTTPStatus 返回HTTP状态码
+func (e *HTTPError) HTTPStatus() int { return e.StatusCode }
+
+// ResponseBody 返回原始响应体
+func (e *HTTPError) ResponseBody() []byte { return e.Body }
+
+// RateLimitError 速率限制（429），RetryAfter 为服务端要求的等待时间，未提供时为0
+type RateLimitError struct {
+	HTTPError
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s (retry after %s)", e.HTTPError.Error(), e.RetryAfter)
+	}
+	return e.HTTPError.Error()
+}
+
+// NoTaskError 当前没有可用任务（获取任务返回404或已分配任务为空）
+type NoTaskError struct {
+	HTTPError
+}
+
+// TaskNotFoundError 提交证明时服务端找不到任务（任务已过期或已被其他节点完成）
+type TaskNotFoundError struct {
+	HTTPError
+}
+
+// UnauthorizedError 认证失败（401/403）
+type UnauthorizedError struct {
+	HTTPError
+}
+
+// ServerError 服务端错误（5xx）
+type ServerError struct {
+	HTTPError
+}
+
+// DecodeError 响应体无法解析为protobuf
+type DecodeError struct {
+	HTTPError
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: decode response (HTTP %d, %d bytes): %v", e.Op, e.StatusCode, len(e.Body), e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// newStatusError 根据HTTP状态码构造对应的类型化错误
+func newStatusError(op string, resp *http.Response, body []byte) error {
+	base := HTTPError{Op: op, StatusCode: resp.StatusCode, Body: body}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{HTTPError: base, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return &UnauthorizedError{HTTPError: base}
+	case resp.StatusCode == http.StatusNotFound && op == opSubmitProof:
+		return &TaskNotFoundError{HTTPError: base}
+	case resp.StatusCode == http.StatusNotFound && (op == opGetNewTask || op == opGetExistingTasks):
+		return &NoTaskError{HTTPError: base}
+	case resp.StatusCode >= 500:
+		return &ServerError{HTTPError: base}
+	default:
+		return &base
+	}
+}
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数和HTTP日期两种格式
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// bodyPreview 截断过长的响应体，避免日志被大段二进制数据刷屏
+func bodyPreview(body []byte) []byte {
+	const max = 512
+	if len(body) <= max {
+		return body
+	}
+	return append(bytes.Clone(body[:max]), "..."...)
+}
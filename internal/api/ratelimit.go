@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"nexus-prover/internal/config"
+)
+
+const (
+	minRateFactor     = 0.125 // 收紧后的速率下限（相对配置速率）
+	recoverRateFactor = 0.1   // 每次成功请求恢复的速率（相对配置速率）
+)
+
+// bucket 令牌桶，rate 会在收到429后收紧，成功请求后逐步恢复
+type bucket struct {
+	capacity     float64   // 桶容量（突发请求数）
+	tokens       float64   // 当前令牌数
+	baseRate     float64   // 配置的速率（令牌/秒）
+	rate         float64   // 当前速率（令牌/秒）
+	last         time.Time // 上次补充令牌的时间
+	blockedUntil time.Time // Retry-After 截止时间，之前不发请求
+}
+
+func newBucket(requests int, window time.Duration, now time.Time) *bucket {
+	rate := float64(requests) / window.Seconds()
+	return &bucket{
+		capacity: float64(requests),
+		tokens:   float64(requests),
+		baseRate: rate,
+		rate:     rate,
+		last:     now,
+	}
+}
+
+// refill 按经过的时间补充令牌
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+}
+
+// wait 返回取得一个令牌还需等待的时间，0 表示可以立即发送
+func (b *bucket) wait(now time.Time) time.Duration {
+	b.refill(now)
+	if now.Before(b.blockedUntil) {
+		return b.blockedUntil.Sub(now)
+	}
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// tighten 收到429：速率减半（不低于下限），清空令牌，并在 retryAfter 内暂停
+func (b *bucket) tighten(now time.Time, retryAfter time.Duration) {
+	b.rate /= 2
+	if min := b.baseRate * minRateFactor; b.rate < min {
+		b.rate = min
+	}
+	b.tokens = 0
+	if until := now.Add(retryAfter); until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+}
+
+// recover 请求成功：速率线性恢复到配置值
+func (b *bucket) recover() {
+	b.rate += b.baseRate * recoverRateFactor
+	if b.rate > b.baseRate {
+		b.rate = b.baseRate
+	}
+}
+
+// RateLimiter 客户端限流器：全局令牌桶 + 每个节点独立的令牌桶
+type RateLimiter struct {
+	mu             sync.Mutex
+	window         time.Duration
+	nodeRequests   int
+	defaultBackoff time.Duration
+	global         *bucket
+	nodes          map[string]*bucket
+}
+
+// NewRateLimiter 根据配置创建限流器，全局与节点请求数均为0时返回 nil（不限流）
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	if cfg.GlobalRequests <= 0 && cfg.NodeRequests <= 0 {
+		return nil
+	}
+	now := time.Now()
+	rl := &RateLimiter{
+		window:         time.Duration(cfg.Window) * time.Second,
+		nodeRequests:   cfg.NodeRequests,
+		defaultBackoff: time.Duration(cfg.Backoff) * time.Second,
+		nodes:          make(map[string]*bucket),
+	}
+	if cfg.GlobalRequests > 0 {
+		rl.global = newBucket(cfg.GlobalRequests, rl.window, now)
+	}
+	return rl
+}
+
+// nodeBucket 获取节点的令牌桶，不存在时创建；nodeID 为空或未配置节点限额时返回 nil
+func (rl *RateLimiter) nodeBucket(nodeID string, now time.Time) *bucket {
+	if nodeID == "" || rl.nodeRequests <= 0 {
+		return nil
+	}
+	b, ok := rl.nodes[nodeID]
+	if !ok {
+		b = newBucket(rl.nodeRequests, rl.window, now)
+		rl.nodes[nodeID] = b
+	}
+	return b
+}
+
+// Wait 阻塞直到全局和节点令牌桶都允许发送请求，或 ctx 被取消
+func (rl *RateLimiter) Wait(ctx context.Context, nodeID string) error {
+	if rl == nil {
+		return nil
+	}
+	for {
+		rl.mu.Lock()
+		now := time.Now()
+		var delay time.Duration
+		node := rl.nodeBucket(nodeID, now)
+		for _, b := range []*bucket{rl.global, node} {
+			if b == nil {
+				continue
+			}
+			if d := b.wait(now); d > delay {
+				delay = d
+			}
+		}
+		if delay == 0 {
+			// 两个桶都有令牌时才同时扣减，避免一个桶空转消耗另一个桶
+			if rl.global != nil {
+				rl.global.tokens--
+			}
+			if node != nil {
+				node.tokens--
+			}
+			rl.mu.Unlock()
+			return nil
+		}
+		rl.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Delay 节点现在发送请求还需等待的时间（不消耗令牌、不阻塞），0 表示可以立即发送；
+// 任务获取据此跳过被限流的节点，避免一个节点的 Retry-After 阻塞其他节点的获取
+func (rl *RateLimiter) Delay(nodeID string) time.Duration {
+	if rl == nil {
+		return 0
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	var delay time.Duration
+	for _, b := range []*bucket{rl.global, rl.nodeBucket(nodeID, now)} {
+		if b == nil {
+			continue
+		}
+		if d := b.wait(now); d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+// OnRateLimited 收到429时收紧：节点暂停 Retry-After（未提供时使用默认退避）并减半速率，全局速率减半
+func (rl *RateLimiter) OnRateLimited(nodeID string, retryAfter time.Duration) {
+	if rl == nil {
+		return
+	}
+	if retryAfter <= 0 {
+		retryAfter = rl.defaultBackoff
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	if node := rl.nodeBucket(nodeID, now); node != nil {
+		node.refill(now)
+		node.tighten(now, retryAfter)
+	}
+	if rl.global != nil {
+		rl.global.refill(now)
+		if nodeID == "" {
+			// 非节点请求被限流，说明是账号或IP级别的限制
+			rl.global.tighten(now, retryAfter)
+		} else {
+			rl.global.tighten(now, 0)
+		}
+	}
+}
+
+// OnSuccess 请求成功后逐步恢复速率
+func (rl *RateLimiter) OnSuccess(nodeID string) {
+	if rl == nil {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.global != nil {
+		rl.global.recover()
+	}
+	if node, ok := rl.nodes[nodeID]; ok {
+		node.recover()
+	}
+}
+
+// Forget 删除节点的令牌桶（节点被移除时调用）
+func (rl *RateLimiter) Forget(nodeID string) {
+	if rl == nil {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	delete(rl.nodes, nodeID)
+}
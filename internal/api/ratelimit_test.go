@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"nexus-prover/internal/config"
+)
+
+// TestBucketRefill 测试令牌按速率补充且不超过桶容量
+func TestBucketRefill(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{"空桶补充", 0, 3 * time.Second, 3},
+		{"部分补充", 2.5, time.Second, 3.5},
+		{"不超过容量", 8, time.Minute, 10},
+		{"时间倒退不补充", 4, -time.Second, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBucket(10, 10*time.Second, now) // 1 令牌/秒
+			b.tokens = tt.tokens
+			b.refill(now.Add(tt.elapsed))
+			if b.tokens != tt.want {
+				t.Errorf("令牌数 = %v, 期望 %v", b.tokens, tt.want)
+			}
+		})
+	}
+}
+
+// TestBucketWait 测试令牌不足和 Retry-After 期间的等待时间
+func TestBucketWait(t *testing.T) {
+	now := time.Now()
+	b := newBucket(2, 2*time.Second, now) // 1 令牌/秒
+	if d := b.wait(now); d != 0 {
+		t.Errorf("满桶等待 %v", d)
+	}
+	b.tokens = 0.5
+	if d := b.wait(now); d != 500*time.Millisecond {
+		t.Errorf("半个令牌等待 %v, 期望 500ms", d)
+	}
+	b.blockedUntil = now.Add(time.Minute)
+	b.tokens = 2
+	if d := b.wait(now); d != time.Minute {
+		t.Errorf("Retry-After 期间等待 %v, 期望 1m", d)
+	}
+}
+
+// TestBucketTightenRecover 测试429后速率减半（不低于下限）并暂停，成功后线性恢复到配置速率
+func TestBucketTightenRecover(t *testing.T) {
+	now := time.Now()
+	b := newBucket(10, 10*time.Second, now)
+	b.tighten(now, 30*time.Second)
+	if b.rate != 0.5 || b.tokens != 0 || !b.blockedUntil.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("收紧后 rate=%v tokens=%v blockedUntil=%v", b.rate, b.tokens, b.blockedUntil.Sub(now))
+	}
+	b.tighten(now, 10*time.Second) // 更短的 Retry-After 不缩短暂停
+	if !b.blockedUntil.Equal(now.Add(30 * time.Second)) {
+		t.Errorf("暂停被缩短到 %v", b.blockedUntil.Sub(now))
+	}
+	for i := 0; i < 10; i++ {
+		b.tighten(now, 0)
+	}
+	if b.rate != minRateFactor {
+		t.Errorf("速率下限 = %v, 期望 %v", b.rate, minRateFactor)
+	}
+
+	wantRates := []float64{0.225, 0.325, 0.425}
+	for _, want := range wantRates {
+		b.recover()
+		if diff := b.rate - want; diff > 1e-9 || diff < -1e-9 {
+			t.Fatalf("恢复后速率 = %v, 期望 %v", b.rate, want)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		b.recover()
+	}
+	if b.rate != b.baseRate {
+		t.Errorf("恢复后速率 = %v, 不应超过配置速率 %v", b.rate, b.baseRate)
+	}
+}
+
+// TestRateLimiterDelay 测试节点的 Retry-After 不影响其他节点，Delay 不消耗令牌
+// （全局限流会随任一节点的429收紧，这里关闭全局限流只看节点令牌桶）
+func TestRateLimiterDelay(t *testing.T) {
+	rl := NewRateLimiter(config.RateLimitConfig{GlobalRequests: -1, NodeRequests: 2, Window: 60, Backoff: 60})
+	if d := rl.Delay("a"); d != 0 {
+		t.Fatalf("初始 Delay = %v", d)
+	}
+	rl.OnRateLimited("a", 10*time.Second)
+	if d := rl.Delay("a"); d < 9*time.Second || d > 10*time.Second {
+		t.Errorf("429 后节点 a 的 Delay = %v, 期望约 10s", d)
+	}
+	if d := rl.Delay("b"); d != 0 {
+		t.Errorf("节点 b 不应受节点 a 的 Retry-After 影响: %v", d)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if rl.Delay("b") != 0 {
+			t.Fatalf("第 %d 个请求前 Delay 应为 0", i+1)
+		}
+		if err := rl.Wait(ctx, "b"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := rl.Delay("b"); d <= 0 {
+		t.Errorf("令牌用完后 Delay = %v, 期望大于0", d)
+	}
+
+	global := NewRateLimiter(config.RateLimitConfig{GlobalRequests: 10, NodeRequests: -1, Window: 10, Backoff: 60})
+	global.OnRateLimited("a", 10*time.Second)
+	if d := global.Delay("b"); d <= 0 || d > 2*time.Second {
+		t.Errorf("节点 429 后全局令牌清空，节点 b 的 Delay = %v, 期望不超过 2s", d)
+	}
+
+	var nilLimiter *RateLimiter
+	if nilLimiter.Delay("a") != 0 || nilLimiter.Wait(ctx, "a") != nil {
+		t.Error("未启用限流时不应等待")
+	}
+}
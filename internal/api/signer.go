@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"nexus-prover/internal/keystore"
+)
+
+// Signer 节点签名器：按节点ID提供公钥并对提交数据签名，私钥不必出现在本进程中
+type Signer interface {
+	// PublicKey 返回节点公钥，获取新任务时上报
+	PublicKey(ctx context.Context, nodeID string) (ed25519.PublicKey, error)
+	// Sign 使用节点私钥对 msg（task_id + proof_hash）签名
+	Sign(ctx context.Context, nodeID string, msg []byte) ([]byte, error)
+}
+
+// KeyStoreSigner 进程内签名器，私钥来自本地密钥库
+type KeyStoreSigner struct {
+	keys *keystore.Store
+}
+
+// NewKeyStoreSigner 创建进程内签名器
+func NewKeyStoreSigner(keys *keystore.Store) *KeyStoreSigner {
+	return &KeyStoreSigner{keys: keys}
+}
+
+// PublicKey 返回节点公钥，密钥库中没有时自动生成
+func (s *KeyStoreSigner) PublicKey(ctx context.Context, nodeID string) (ed25519.PublicKey, error) {
+	return s.keys.PublicKey(nodeID)
+}
+
+// Sign 使用节点私钥签名
+func (s *KeyStoreSigner) Sign(ctx context.Context, nodeID string, msg []byte) ([]byte, error) {
+	priv, err := s.keys.Get(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.Sign(priv, msg), nil
+}
+
+// SetSigner 设置签名器，获取新任务和提交证明时使用对应节点的密钥
+func (c *Client) SetSigner(signer Signer) {
+	c.signer = signer
+}
+
+// publicKey 获取节点公钥
+func (c *Client) publicKey(ctx context.Context, nodeID string) (ed25519.PublicKey, error) {
+	if c.signer == nil {
+		return nil, errors.New("未设置签名器")
+	}
+	pub, err := c.signer.PublicKey(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("获取节点 %s 的公钥失败: %w", nodeID, err)
+	}
+	return pub, nil
+}
+
+// sign 使用节点私钥签名
+func (c *Client) sign(ctx context.Context, nodeID string, msg []byte) ([]byte, error) {
+	if c.signer == nil {
+		return nil, errors.New("未设置签名器")
+	}
+	signature, err := c.signer.Sign(ctx, nodeID, msg)
+	if err != nil {
+		return nil, fmt.Errorf("节点 %s 签名失败: %w", nodeID, err)
+	}
+	return signature, nil
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// 签名服务接口路径（Unix socket 上的 HTTP）
+const (
+	SIGNER_PATH_PUBLIC_KEY = "/v1/public-key"
+	SIGNER_PATH_SIGN       = "/v1/sign"
+)
+
+// SignerRequest 签名服务请求，[]byte 字段以 base64 编码
+type SignerRequest struct {
+	NodeID  string `json:"node_id"`
+	Message []byte `json:"message,omitempty"`
+}
+
+// SignerResponse 签名服务响应，出错时只有 Error
+type SignerResponse struct {
+	PublicKey []byte `json:"public_key,omitempty"`
+	Signature []byte `json:"signature,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SignerError 签名服务返回的错误（如节点不在白名单中）
+type SignerError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *SignerError) Error() string {
+	return fmt.Sprintf("签名服务返回 HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// SocketSigner 通过 Unix socket 调用本地签名服务（nexus-signer），私钥只保存在签名服务进程中
+type SocketSigner struct {
+	socketPath string
+	httpClient *http.Client
+}
+
+// NewSocketSigner 创建签名服务客户端，不会立即连接
+func NewSocketSigner(socketPath string) *SocketSigner {
+	return &SocketSigner{
+		socketPath: socketPath,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socketPath)
+				},
+				MaxIdleConns:    4,
+				IdleConnTimeout: 90 * time.Second,
+			},
+			// 本地签名应当很快，防止签名服务卡死导致提交一直阻塞
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// SocketPath 签名服务 socket 路径
+func (s *SocketSigner) SocketPath() string {
+	return s.socketPath
+}
+
+// PublicKey 向签名服务查询节点公钥
+func (s *SocketSigner) PublicKey(ctx context.Context, nodeID string) (ed25519.PublicKey, error) {
+	resp, err := s.call(ctx, SIGNER_PATH_PUBLIC_KEY, &SignerRequest{NodeID: nodeID})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.PublicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("签名服务返回的公钥长度错误: %d", len(resp.PublicKey))
+	}
+	return ed25519.PublicKey(resp.PublicKey), nil
+}
+
+// Sign 请求签名服务对 msg 签名
+func (s *SocketSigner) Sign(ctx context.Context, nodeID string, msg []byte) ([]byte, error) {
+	resp, err := s.call(ctx, SIGNER_PATH_SIGN, &SignerRequest{NodeID: nodeID, Message: msg})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("签名服务返回的签名长度错误: %d", len(resp.Signature))
+	}
+	return resp.Signature, nil
+}
+
+// call 发送请求并解析响应
+func (s *SocketSigner) call(ctx context.Context, path string, req *SignerRequest) (*SignerResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	// Host 仅用于构造URL，实际连接由 DialContext 指向 socket
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://nexus-signer"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("连接签名服务 %s 失败: %w", s.socketPath, err)
+	}
+	defer httpResp.Body.Close()
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp SignerResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("解析签名服务响应失败 (HTTP %d): %v", httpResp.StatusCode, err)
+	}
+	if httpResp.StatusCode != http.StatusOK || resp.Error != "" {
+		return nil, &SignerError{StatusCode: httpResp.StatusCode, Message: resp.Error}
+	}
+	return &resp, nil
+}
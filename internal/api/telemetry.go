@@ -0,0 +1,50 @@
+package api
+
+import (
+	"math"
+
+	"nexus-prover/internal/telemetry"
+	"nexus-prover/pkg/types"
+	pb "nexus-prover/proto"
+)
+
+// SetTelemetry 设置提交证明时上报的遥测数据来源
+func (c *Client) SetTelemetry(collector *telemetry.Collector) {
+	c.telemetry = collector
+}
+
+// nodeTelemetry 构造任务提交时的节点遥测数据
+func (c *Client) nodeTelemetry(task *types.Task) *pb.NodeTelemetry {
+	if c.telemetry == nil {
+		location := telemetry.DEFAULT_LOCATION
+		return &pb.NodeTelemetry{Location: &location}
+	}
+	sample := c.telemetry.Sample(task.TaskID, c.telemetry.NodeLocation(task.NodeID))
+	nt := &pb.NodeTelemetry{Location: &sample.Location}
+	if sample.FlopsPerSec > 0 {
+		v := clampInt32(sample.FlopsPerSec)
+		nt.FlopsPerSec = &v
+	}
+	if sample.MemoryUsed > 0 {
+		v := clampInt32(sample.MemoryUsed)
+		nt.MemoryUsed = &v
+	}
+	if sample.MemoryCapacity > 0 {
+		v := clampInt32(sample.MemoryCapacity)
+		nt.MemoryCapacity = &v
+	}
+	return nt
+}
+
+// clampInt32 proto字段为int32，超出范围时截断为最大值。
+// 协议规定内存字段单位为字节、flops_per_sec 单位为次/秒，不能自行换算单位，
+// 因此内存超过 2GiB、算力超过约 2.1 GFLOPS 时上报的都是 math.MaxInt32，只表示"不低于该值"
+func clampInt32(v int64) int32 {
+	if v > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	if v < 0 {
+		return 0
+	}
+	return int32(v)
+}
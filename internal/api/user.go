@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	pb "nexus-prover/proto"
+)
+
+// 用户节点列表最多翻页数，防止服务端游标异常导致死循环
+const maxUserNodePages = 100
+
+// RegisterUser 注册用户（uuid + 钱包地址）
+func (c *Client) RegisterUser(ctx context.Context, userID, walletAddress string) error {
+	req := &pb.RegisterUserRequest{
+		Uuid:          userID,
+		WalletAddress: walletAddress,
+	}
+	_, err := c.roundTrip(ctx, opRegisterUser, "", http.MethodPost, c.profile.URL("users"), req)
+	return err
+}
+
+// RegisterNode 为用户注册一个CLI节点，返回新节点ID
+func (c *Client) RegisterNode(ctx context.Context, userID string) (string, error) {
+	req := &pb.RegisterNodeRequest{
+		NodeType: pb.NodeType_CLI_PROVER,
+		UserId:   userID,
+	}
+	respData, err := c.roundTrip(ctx, opRegisterNode, "", http.MethodPost, c.profile.URL("nodes"), req)
+	if err != nil {
+		return "", err
+	}
+
+	var nodeResp pb.RegisterNodeResponse
+	if err := decode(opRegisterNode, respData, &nodeResp); err != nil {
+		return "", err
+	}
+	return nodeResp.NodeId, nil
+}
+
+// GetUser 查询用户信息及一页节点列表，user 为钱包地址（或用户ID），cursor 为空时取第一页
+func (c *Client) GetUser(ctx context.Context, user, cursor string) (*pb.UserResponse, error) {
+	u := c.profile.URL("users", url.PathEscape(user))
+	if cursor != "" {
+		u += "?" + url.Values{"nodes_cursor": {cursor}}.Encode()
+	}
+	respData, err := c.roundTrip(ctx, opGetUser, "", http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var userResp pb.UserResponse
+	if err := decode(opGetUser, respData, &userResp); err != nil {
+		return nil, err
+	}
+	return &userResp, nil
+}
+
+// ListUserNodes 按 nodes_next_cursor 翻页获取用户的全部节点
+func (c *Client) ListUserNodes(ctx context.Context, user string) ([]*pb.Node, error) {
+	var nodes []*pb.Node
+	cursor := ""
+	for page := 0; page < maxUserNodePages; page++ {
+		userResp, err := c.GetUser(ctx, user, cursor)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, userResp.Nodes...)
+		if userResp.NodesNextCursor == "" || userResp.NodesNextCursor == cursor {
+			break
+		}
+		cursor = userResp.NodesNextCursor
+	}
+	return nodes, nil
+}
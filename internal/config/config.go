@@ -2,51 +2,410 @@ package config
 
 import (
 	"encoding/json"
-	"io/ioutil"
+	"reflect"
+	"time"
 )
 
 // Config 配置结构体
 type Config struct {
-	NodeIDs                []string `json:"node_ids"` // 节点ID数组
-	UserID                 string   `json:"user_id"`
-	WalletAddress          string   `json:"wallet_address"`
-	RequestDelay           int      `json:"request_delay"`             // 请求间隔（秒）
-	ProverWorkers          int      `json:"prover_workers"`            // 证明计算worker数量
-	ProverSubmitWaitSecond int      `json:"prover_submit_wait_second"` // 证明提交等待时间
-	TaskQueueCapacity      int      `json:"task_queue_capacity"`       // 任务队列容量
+	Nodes                  []NodeConfig `json:"node_ids"` // 节点数组，元素为节点ID字符串或带节点级覆盖的对象
+	UserID                 string       `json:"user_id"`
+	WalletAddress          string       `json:"wallet_address"`
+	RequestDelay           int          `json:"request_delay"`             // 请求间隔（秒）
+	ProverWorkers          int          `json:"prover_workers"`            // 证明计算worker数量
+	ProverSubmitWaitSecond int          `json:"prover_submit_wait_second"` // 证明提交等待时间
+	TaskQueueCapacity      int          `json:"task_queue_capacity"`       // 任务队列容量
+	ExistingTasksMaxPages  int          `json:"existing_tasks_max_pages"`  // 获取已分配任务时最多翻页数
+	NodeDiscovery          string       `json:"node_discovery"`            // 节点自动发现: off / merge / replace
+	NodeDiscoveryInterval  int          `json:"node_discovery_interval"`   // 节点列表刷新间隔（秒）
+	TelemetryLocation      string       `json:"telemetry_location"`        // 遥测上报的地理位置
+	KeyDir                 string       `json:"key_dir"`                   // 节点密钥目录
+	DeadLetterDir          string       `json:"dead_letter_dir"`           // 放弃重试的证明保存目录
+
+	BatchSize          int `json:"batch_size"`           // 每次获取新任务的数量
+	MaxConsecutive404s int `json:"max_consecutive_404s"` // 批量获取新任务时连续无任务次数达到该值即停止本轮
+	TaskFetchInterval  int `json:"task_fetch_interval"`  // 每个节点获取任务的固定间隔（秒）
+	QueueLogInterval   int `json:"queue_log_interval"`   // 节点队列日志间隔（秒）
+	RetryMaxAttempts   int `json:"retry_max_attempts"`   // 证明提交失败后最多重试次数
+	RetryQueueCapacity int `json:"retry_queue_capacity"` // 提交失败重试队列容量
+	StatsInterval      int `json:"stats_interval"`       // 周期统计输出间隔（秒）
+
+	RetryBackoff RetryBackoffConfig `json:"retry_backoff"` // 提交失败重试的退避策略
+
+	ProcessIsolation ProcessIsolationConfig `json:"process_isolation"` // 进程隔离模式（-ps）
+
+	Queue QueueConfig `json:"queue"` // 任务队列存储与调度
+
+	Signer SignerConfig `json:"signer"` // 签名方式
+
+	RateLimit RateLimitConfig `json:"rate_limit"` // 客户端限流
+
+	Environment string             `json:"environment"` // 编排服务环境: beta / prod / local 或自定义
+	Profiles    map[string]Profile `json:"profiles"`    // 自定义环境或覆盖内置环境
+
+	sources map[string]string // 字段路径 => 值来源，见 Source
+}
+
+// SignerConfig 签名方式：local 使用进程内密钥库，socket 通过 Unix socket 调用 nexus-signer
+type SignerConfig struct {
+	Type   string `json:"type"`   // local / socket
+	Socket string `json:"socket"` // nexus-signer 的 socket 路径
+}
+
+// RetryBackoffConfig 提交失败重试的退避：第 n 次重试前等待 base_delay×multiplier^(n-1) 秒，
+// 不超过 max_delay，再加减 jitter 比例的随机抖动；429 返回的 Retry-After 更长时以其为准
+type RetryBackoffConfig struct {
+	BaseDelay     int            `json:"base_delay"`     // 第1次重试前的等待时间（秒）
+	MaxDelay      int            `json:"max_delay"`      // 等待时间上限（秒）
+	Multiplier    float64        `json:"multiplier"`     // 每次重试等待时间的倍数
+	Jitter        float64        `json:"jitter"`         // 随机抖动比例（0-1），-1 表示不抖动
+	ClassAttempts map[string]int `json:"class_attempts"` // 按错误类型覆盖 retry_max_attempts，0 表示该类型不重试
+}
+
+// JitterFraction 生效的抖动比例，-1 换算为 0
+func (r RetryBackoffConfig) JitterFraction() float64 {
+	if r.Jitter == -1 {
+		return 0
+	}
+	return r.Jitter
+}
+
+// ProcessIsolationConfig 进程隔离模式配置
+type ProcessIsolationConfig struct {
+	MaxLifetime int `json:"max_lifetime"` // 单个证明子进程的超时时间（秒）
+	MaxRestarts int `json:"max_restarts"` // 子进程连续失败次数上限，达到后不再启动子进程
+}
+
+// QueueConfig 任务队列存储：memory 为纯内存队列（默认），disk 通过预写日志持久化，
+// 重启或崩溃后恢复排队中、正在计算和等待重新提交的任务
+type QueueConfig struct {
+	Backend        string `json:"backend"`         // memory / disk
+	Dir            string `json:"dir"`             // disk 模式的日志目录
+	Fsync          string `json:"fsync"`           // 刷盘策略: always / interval / never
+	FsyncInterval  int    `json:"fsync_interval"`  // interval 策略的刷盘间隔（毫秒）
+	CompactRecords int    `json:"compact_records"` // 日志记录数超过该值且超过一半已失效时压缩
+
+	DedupTTL  int `json:"dedup_ttl"`  // 已结束的任务ID保留多久（秒），期间再次获取到同一任务时跳过
+	DedupSize int `json:"dedup_size"` // 最多保留多少个已结束的任务ID
+
+	HighWater int `json:"high_water"` // 队列深度达到容量的该百分比时暂停获取任务
+	LowWater  int `json:"low_water"`  // 暂停后队列深度回落到容量的该百分比时恢复获取，-1 表示队列清空后才恢复
+
+	TaskTTL    int            `json:"task_ttl"`    // 任务有效期（秒，从服务端创建时间或获取时间算起），0 表示不限制
+	ProgramTTL map[string]int `json:"program_ttl"` // 按程序ID覆盖任务有效期（秒）
+
+	Scheduling string         `json:"scheduling"` // 出队顺序: fifo（默认）/ priority
+	Fairness   string         `json:"fairness"`   // 按节点公平出队: off（默认）/ round_robin / weighted（按节点 weight）
+	Priority   PriorityConfig `json:"priority"`   // priority 调度的打分权重
+}
+
+// PriorityConfig 优先级调度打分: 分数 = 任务年龄秒数×age_weight + 已分配任务×existing_bonus
+// + program_bonus[程序ID] - 预估证明耗时秒数×cost_weight，分数高的先计算；权重设为 -1 表示不计入
+type PriorityConfig struct {
+	AgeWeight     float64            `json:"age_weight"`     // 任务年龄（距服务端创建时间）每秒加分
+	ExistingBonus float64            `json:"existing_bonus"` // 已分配给本节点的任务加分
+	ProgramBonus  map[string]float64 `json:"program_bonus"`  // 按程序ID加分
+	CostWeight    float64            `json:"cost_weight"`    // 预估证明耗时每秒扣分
+	ProgramCosts  map[string]int     `json:"program_costs"`  // 各程序的初始预估证明耗时（秒），运行中按实际耗时修正
+	DefaultCost   int                `json:"default_cost"`   // 未配置程序的初始预估证明耗时（秒）
+}
+
+// Weights 生效的年龄、已分配任务、耗时权重，-1 换算为 0
+func (p PriorityConfig) Weights() (age, existing, cost float64) {
+	weight := func(w float64) float64 {
+		if w == -1 {
+			return 0
+		}
+		return w
+	}
+	return weight(p.AgeWeight), weight(p.ExistingBonus), weight(p.CostWeight)
+}
+
+// LowWaterPercent 生效的低水位百分比，-1 换算为 0
+func (q QueueConfig) LowWaterPercent() int {
+	if q.LowWater == -1 {
+		return 0
+	}
+	return q.LowWater
+}
+
+// SyncInterval 刷盘策略对应的刷盘间隔: 0 每条记录刷盘，>0 按间隔刷盘，<0 不主动刷盘
+func (q QueueConfig) SyncInterval() time.Duration {
+	switch q.Fsync {
+	case QUEUE_FSYNC_ALWAYS:
+		return 0
+	case QUEUE_FSYNC_NEVER:
+		return -1
+	default:
+		return time.Duration(q.FsyncInterval) * time.Millisecond
+	}
+}
+
+// RateLimitConfig 客户端限流配置（令牌桶），请求数设为 -1 表示不限制
+type RateLimitConfig struct {
+	GlobalRequests int `json:"global_requests"` // 所有节点共享，每个窗口允许的请求数
+	NodeRequests   int `json:"node_requests"`   // 每个节点每个窗口允许的请求数
+	Window         int `json:"window"`          // 窗口长度（秒）
+	Backoff        int `json:"backoff"`         // 429 未返回 Retry-After 时的退避时间（秒）
 }
 
 // 常量定义
 const (
-	// 批处理配置
-	BATCH_SIZE                = 3 // 每次获取3个任务
-	MAX_404S_BEFORE_GIVING_UP = 5
-	TASK_FETCH_INTERVAL       = 180 // 180秒固定间隔获取任务
-	QUEUE_LOG_INTERVAL        = 30  // 30秒打印日志时间间隔
-
-	// 任务API地址
-	TASKS_API_URL    = "https://beta.orchestrator.nexus.xyz/v3/tasks"
-	TASKS_SUBMIT_URL = "https://beta.orchestrator.nexus.xyz/v3/tasks/submit"
+	// 任务获取与调度 - 默认值，可通过配置文件覆盖
+	DEFAULT_BATCH_SIZE           = 3   // 每次获取3个任务
+	DEFAULT_MAX_CONSECUTIVE_404S = 5   // 连续5次无任务停止本轮获取
+	DEFAULT_TASK_FETCH_INTERVAL  = 180 // 180秒固定间隔获取任务
+	DEFAULT_QUEUE_LOG_INTERVAL   = 30  // 30秒打印日志时间间隔
+	DEFAULT_RETRY_MAX_ATTEMPTS   = 3   // 提交失败最多重试3次
+	DEFAULT_RETRY_QUEUE_CAPACITY = 100 // 提交失败重试队列容量
+	DEFAULT_STATS_INTERVAL       = 60  // 周期统计间隔（秒）
+	DEFAULT_RETRY_BASE_DELAY     = 5   // 第1次重试前等待5秒
+	DEFAULT_RETRY_MAX_DELAY      = 300 // 重试等待最长5分钟
+	DEFAULT_RETRY_MULTIPLIER     = 2   // 每次重试等待时间翻倍
+	DEFAULT_RETRY_JITTER         = 0.2 // 等待时间随机加减20%
+	DEFAULT_PROCESS_MAX_LIFETIME = 300 // 证明子进程超时5分钟
+	DEFAULT_PROCESS_MAX_RESTARTS = 3   // 证明子进程最多连续失败3次
 
 	// 队列配置 - 默认值，可通过配置文件覆盖
-	DEFAULT_TASK_QUEUE_CAPACITY = 1000 // 默认任务队列容量
+	DEFAULT_TASK_QUEUE_CAPACITY      = 1000 // 默认任务队列容量
+	DEFAULT_EXISTING_TASKS_MAX_PAGES = 10   // 默认已分配任务最多翻页数
+	DEFAULT_NODE_DISCOVERY_INTERVAL  = 600  // 默认节点列表刷新间隔（秒）
+
+	// 任务队列存储
+	QUEUE_MEMORY                  = "memory"   // 纯内存队列，重启后丢失
+	QUEUE_DISK                    = "disk"     // 预写日志持久化
+	QUEUE_FSYNC_ALWAYS            = "always"   // 每条记录写入后刷盘
+	QUEUE_FSYNC_INTERVAL          = "interval" // 后台按间隔刷盘，掉电时最多丢失一个间隔内的记录
+	QUEUE_FSYNC_NEVER             = "never"    // 不主动刷盘，进程崩溃不丢失，掉电可能丢失
+	DEFAULT_QUEUE_DIR             = "queue"    // 默认日志目录
+	DEFAULT_QUEUE_FSYNC_INTERVAL  = 1000       // 默认刷盘间隔（毫秒）
+	DEFAULT_QUEUE_COMPACT_RECORDS = 10000      // 默认压缩阈值（记录数）
+	DEFAULT_QUEUE_DEDUP_TTL       = 3600       // 已结束的任务ID保留1小时
+	DEFAULT_QUEUE_DEDUP_SIZE      = 10000      // 最多保留1万个已结束的任务ID
+	DEFAULT_QUEUE_HIGH_WATER      = 90         // 队列90%满时暂停获取
+	DEFAULT_QUEUE_LOW_WATER       = 50         // 回落到50%时恢复获取
+	SCHEDULING_FIFO               = "fifo"     // 先进先出
+	SCHEDULING_PRIORITY           = "priority" // 按打分函数优先级出队
+	DEFAULT_PRIORITY_AGE_WEIGHT   = 1          // 任务年龄每秒加1分
+	DEFAULT_PRIORITY_EXISTING     = 300        // 已分配任务加300分（相当于早5分钟创建）
+	DEFAULT_PRIORITY_COST_WEIGHT  = 1          // 预估耗时每秒扣1分
+	DEFAULT_PRIORITY_DEFAULT_COST = 60         // 未知程序预估证明耗时60秒
+
+	// 按节点公平出队
+	FAIRNESS_OFF         = "off"         // 不区分节点
+	FAIRNESS_ROUND_ROBIN = "round_robin" // 各节点轮流，证明时间均分
+	FAIRNESS_WEIGHTED    = "weighted"    // 证明时间按节点 weight 分配
+
+	// 死信目录
+	DEFAULT_DEAD_LETTER_DIR = "dead_letter" // 默认放弃重试的证明保存目录
+
+	// 节点密钥
+	DEFAULT_KEY_DIR    = "keys"                 // 默认节点密钥目录
+	KEY_PASSPHRASE_ENV = "NEXUS_KEY_PASSPHRASE" // 密钥加密口令的环境变量，为空时明文保存
+
+	// 签名方式
+	SIGNER_LOCAL          = "local"             // 进程内密钥库
+	SIGNER_SOCKET         = "socket"            // 外部签名服务 nexus-signer
+	DEFAULT_SIGNER_SOCKET = "nexus-signer.sock" // 默认签名服务 socket 路径
+
+	// 限流默认值
+	DEFAULT_RATE_LIMIT_GLOBAL_REQUESTS = 60 // 全局每窗口请求数
+	DEFAULT_RATE_LIMIT_NODE_REQUESTS   = 10 // 每节点每窗口请求数
+	DEFAULT_RATE_LIMIT_WINDOW          = 60 // 窗口长度（秒）
+	DEFAULT_RATE_LIMIT_BACKOFF         = 60 // 429 默认退避（秒）
+
+	// 提交失败的错误类型（retry_backoff.class_attempts 的键）
+	RETRY_CLASS_RATE_LIMIT   = "rate_limit"   // 429 速率限制
+	RETRY_CLASS_SERVER       = "server"       // 5xx 或响应无法解析
+	RETRY_CLASS_UNAUTHORIZED = "unauthorized" // 401/403 认证失败
+	RETRY_CLASS_CLIENT       = "client"       // 其他 4xx
+	RETRY_CLASS_NETWORK      = "network"      // 连接失败、超时等网络错误
+
+	// 节点自动发现模式
+	NODE_DISCOVERY_OFF     = "off"     // 只使用 node_ids
+	NODE_DISCOVERY_MERGE   = "merge"   // node_ids 与用户CLI节点合并
+	NODE_DISCOVERY_REPLACE = "replace" // 只使用用户CLI节点
 )
 
-// LoadConfig 加载配置文件
+// LoadConfig 加载配置文件并应用 NEXUS_* 环境变量，见 Load
 func LoadConfig(path string) (*Config, error) {
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
+	return Load(LoadOptions{Path: path})
+}
+
+// decodeConfig 解析 JSON 配置、填充默认值并校验，未知字段或字段值非法时返回 *ValidationError（包含全部错误），
+// 同时返回已解析的配置，供 config validate 继续检查其他项目
+func decodeConfig(data []byte) (*Config, error) {
+	// 先整体检查语法和顶层是否为对象，再逐个字段解析以收集全部类型错误
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
 		return nil, err
 	}
 	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
-	}
+	var typeErrs []FieldError
+	decodeFields(&typeErrs, "", data, reflect.ValueOf(&cfg).Elem())
+	fieldErrs := append(unknownFields(data), typeErrs...)
 
-	// 设置默认值
-	if cfg.TaskQueueCapacity <= 0 {
+	// 设置默认值（只填充未配置的字段，负数等非法值交给 Validate 报错）
+	if cfg.TaskQueueCapacity == 0 {
 		cfg.TaskQueueCapacity = DEFAULT_TASK_QUEUE_CAPACITY
 	}
+	if cfg.ExistingTasksMaxPages == 0 {
+		cfg.ExistingTasksMaxPages = DEFAULT_EXISTING_TASKS_MAX_PAGES
+	}
+	if cfg.NodeDiscovery == "" {
+		cfg.NodeDiscovery = NODE_DISCOVERY_OFF
+	}
+	if cfg.NodeDiscoveryInterval == 0 {
+		cfg.NodeDiscoveryInterval = DEFAULT_NODE_DISCOVERY_INTERVAL
+	}
+	if cfg.KeyDir == "" {
+		cfg.KeyDir = DEFAULT_KEY_DIR
+	}
+	if cfg.DeadLetterDir == "" {
+		cfg.DeadLetterDir = DEFAULT_DEAD_LETTER_DIR
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = DEFAULT_BATCH_SIZE
+	}
+	if cfg.MaxConsecutive404s == 0 {
+		cfg.MaxConsecutive404s = DEFAULT_MAX_CONSECUTIVE_404S
+	}
+	if cfg.TaskFetchInterval == 0 {
+		cfg.TaskFetchInterval = DEFAULT_TASK_FETCH_INTERVAL
+	}
+	if cfg.QueueLogInterval == 0 {
+		cfg.QueueLogInterval = DEFAULT_QUEUE_LOG_INTERVAL
+	}
+	if cfg.RetryMaxAttempts == 0 {
+		cfg.RetryMaxAttempts = DEFAULT_RETRY_MAX_ATTEMPTS
+	}
+	if cfg.RetryQueueCapacity == 0 {
+		cfg.RetryQueueCapacity = DEFAULT_RETRY_QUEUE_CAPACITY
+	}
+	if cfg.StatsInterval == 0 {
+		cfg.StatsInterval = DEFAULT_STATS_INTERVAL
+	}
+	if cfg.RetryBackoff.BaseDelay == 0 {
+		cfg.RetryBackoff.BaseDelay = DEFAULT_RETRY_BASE_DELAY
+	}
+	if cfg.RetryBackoff.MaxDelay == 0 {
+		cfg.RetryBackoff.MaxDelay = DEFAULT_RETRY_MAX_DELAY
+	}
+	if cfg.RetryBackoff.Multiplier == 0 {
+		cfg.RetryBackoff.Multiplier = DEFAULT_RETRY_MULTIPLIER
+	}
+	if cfg.RetryBackoff.Jitter == 0 {
+		cfg.RetryBackoff.Jitter = DEFAULT_RETRY_JITTER
+	}
+	if cfg.ProcessIsolation.MaxLifetime == 0 {
+		cfg.ProcessIsolation.MaxLifetime = DEFAULT_PROCESS_MAX_LIFETIME
+	}
+	if cfg.ProcessIsolation.MaxRestarts == 0 {
+		cfg.ProcessIsolation.MaxRestarts = DEFAULT_PROCESS_MAX_RESTARTS
+	}
+	if cfg.Queue.Backend == "" {
+		cfg.Queue.Backend = QUEUE_MEMORY
+	}
+	if cfg.Queue.Dir == "" {
+		cfg.Queue.Dir = DEFAULT_QUEUE_DIR
+	}
+	if cfg.Queue.Fsync == "" {
+		cfg.Queue.Fsync = QUEUE_FSYNC_INTERVAL
+	}
+	if cfg.Queue.FsyncInterval == 0 {
+		cfg.Queue.FsyncInterval = DEFAULT_QUEUE_FSYNC_INTERVAL
+	}
+	if cfg.Queue.CompactRecords == 0 {
+		cfg.Queue.CompactRecords = DEFAULT_QUEUE_COMPACT_RECORDS
+	}
+	if cfg.Queue.DedupTTL == 0 {
+		cfg.Queue.DedupTTL = DEFAULT_QUEUE_DEDUP_TTL
+	}
+	if cfg.Queue.DedupSize == 0 {
+		cfg.Queue.DedupSize = DEFAULT_QUEUE_DEDUP_SIZE
+	}
+	if cfg.Queue.HighWater == 0 {
+		cfg.Queue.HighWater = DEFAULT_QUEUE_HIGH_WATER
+	}
+	if cfg.Queue.LowWater == 0 {
+		// 默认低水位不能高于配置的高水位，只配置了较低的 high_water 时取其一半
+		cfg.Queue.LowWater = DEFAULT_QUEUE_LOW_WATER
+		if cfg.Queue.HighWater > 0 && cfg.Queue.LowWater >= cfg.Queue.HighWater {
+			cfg.Queue.LowWater = cfg.Queue.HighWater / 2
+			if cfg.Queue.LowWater == 0 {
+				cfg.Queue.LowWater = -1
+			}
+		}
+	}
+	if cfg.Queue.Scheduling == "" {
+		cfg.Queue.Scheduling = SCHEDULING_FIFO
+	}
+	if cfg.Queue.Fairness == "" {
+		cfg.Queue.Fairness = FAIRNESS_OFF
+	}
+	if cfg.Queue.Priority.AgeWeight == 0 {
+		cfg.Queue.Priority.AgeWeight = DEFAULT_PRIORITY_AGE_WEIGHT
+	}
+	if cfg.Queue.Priority.ExistingBonus == 0 {
+		cfg.Queue.Priority.ExistingBonus = DEFAULT_PRIORITY_EXISTING
+	}
+	if cfg.Queue.Priority.CostWeight == 0 {
+		cfg.Queue.Priority.CostWeight = DEFAULT_PRIORITY_COST_WEIGHT
+	}
+	if cfg.Queue.Priority.DefaultCost == 0 {
+		cfg.Queue.Priority.DefaultCost = DEFAULT_PRIORITY_DEFAULT_COST
+	}
+	if cfg.Signer.Type == "" {
+		cfg.Signer.Type = SIGNER_LOCAL
+	}
+	if cfg.Signer.Socket == "" {
+		cfg.Signer.Socket = DEFAULT_SIGNER_SOCKET
+	}
+	if cfg.RateLimit.GlobalRequests == 0 {
+		cfg.RateLimit.GlobalRequests = DEFAULT_RATE_LIMIT_GLOBAL_REQUESTS
+	}
+	if cfg.RateLimit.NodeRequests == 0 {
+		cfg.RateLimit.NodeRequests = DEFAULT_RATE_LIMIT_NODE_REQUESTS
+	}
+	if cfg.RateLimit.Window == 0 {
+		cfg.RateLimit.Window = DEFAULT_RATE_LIMIT_WINDOW
+	}
+	if cfg.RateLimit.Backoff == 0 {
+		cfg.RateLimit.Backoff = DEFAULT_RATE_LIMIT_BACKOFF
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = DEFAULT_ENVIRONMENT
+	}
+
+	if err := cfg.Validate(); err != nil || len(fieldErrs) > 0 {
+		verr := &ValidationError{Errors: fieldErrs}
+		if ve, ok := err.(*ValidationError); ok {
+			// 类型错误的字段按零值校验，不再重复报告
+			invalid := make(map[string]bool, len(typeErrs))
+			for _, fe := range typeErrs {
+				invalid[fe.Field] = true
+			}
+			for _, fe := range ve.Errors {
+				if !invalid[fe.Field] {
+					verr.Errors = append(verr.Errors, fe)
+				}
+			}
+		}
+		return &cfg, verr
+	}
 
 	return &cfg, nil
 }
+
+// DiscoveryEnabled 是否启用节点自动发现
+func (c *Config) DiscoveryEnabled() bool {
+	return c.NodeDiscovery == NODE_DISCOVERY_MERGE || c.NodeDiscovery == NODE_DISCOVERY_REPLACE
+}
+
+// UserKey 查询用户节点列表使用的标识：优先钱包地址，未配置时使用用户ID
+func (c *Config) UserKey() string {
+	if c.WalletAddress != "" {
+		return c.WalletAddress
+	}
+	return c.UserID
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// UpdateConfigFile 读取配置文件为原始JSON对象，由 update 修改后原子写回，未知字段原样保留
+func UpdateConfigFile(path string, update func(raw map[string]json.RawMessage) error) error {
+	if format := FileFormat(path); format != FORMAT_JSON {
+		return fmt.Errorf("只支持写回 JSON 配置文件，%s 为 %s 格式，请手动修改", path, format)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	raw := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("解析配置文件失败: %v", err)
+	}
+	if err := update(raw); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(out, '\n'))
+}
+
+// AddNodeIDs 将节点ID追加到配置文件的 node_ids（已存在的跳过，带节点级覆盖的对象原样保留），返回实际新增的ID
+func AddNodeIDs(path string, ids ...string) ([]string, error) {
+	var added []string
+	err := UpdateConfigFile(path, func(raw map[string]json.RawMessage) error {
+		var nodes []NodeConfig
+		if v, ok := raw["node_ids"]; ok {
+			if err := json.Unmarshal(v, &nodes); err != nil {
+				return fmt.Errorf("node_ids 格式错误: %v", err)
+			}
+		}
+		exists := make(map[string]bool, len(nodes))
+		for _, n := range nodes {
+			exists[n.ID] = true
+		}
+		for _, id := range ids {
+			if id == "" || exists[id] {
+				continue
+			}
+			exists[id] = true
+			nodes = append(nodes, NodeConfig{ID: id})
+			added = append(added, id)
+		}
+		return setRaw(raw, "node_ids", nodes)
+	})
+	return added, err
+}
+
+// SetUser 将用户ID和钱包地址写回配置文件
+func SetUser(path, userID, walletAddress string) error {
+	return UpdateConfigFile(path, func(raw map[string]json.RawMessage) error {
+		if err := setRaw(raw, "user_id", userID); err != nil {
+			return err
+		}
+		return setRaw(raw, "wallet_address", walletAddress)
+	})
+}
+
+func setRaw(raw map[string]json.RawMessage, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	raw[key] = data
+	return nil
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免写入中断导致配置文件损坏
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
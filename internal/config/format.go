@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置文件格式，按扩展名识别，其他扩展名按 JSON 解析
+const (
+	FORMAT_JSON = "json"
+	FORMAT_YAML = "yaml"
+	FORMAT_TOML = "toml"
+)
+
+// FileFormat 根据扩展名判断配置文件格式
+func FileFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FORMAT_YAML
+	case ".toml":
+		return FORMAT_TOML
+	default:
+		return FORMAT_JSON
+	}
+}
+
+// decodeFile 将配置文件解析为原始对象，YAML/TOML 的值按 Config 字段类型转换，结果与 JSON 解析一致
+func decodeFile(path string, data []byte) (map[string]interface{}, error) {
+	var tree interface{}
+	var err error
+	switch FileFormat(path) {
+	case FORMAT_YAML:
+		tree, err = parseYAML(string(data))
+	case FORMAT_TOML:
+		tree, err = parseTOML(string(data))
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&tree)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tree == nil {
+		return make(map[string]interface{}), nil
+	}
+	obj, ok := tree.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("配置文件顶层必须是对象")
+	}
+	return resolveValue(obj, reflect.TypeOf(Config{})).(map[string]interface{}), nil
+}
+
+// plainScalar YAML 未加引号的标量，类型由目标字段决定（如 node_ids 中的 12739613 按字符串处理）
+type plainScalar string
+
+// resolveValue 按目标类型转换解析结果中的标量，t 为 nil 表示未知字段，按字面推断类型
+func resolveValue(v interface{}, t reflect.Type) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		var fields map[string]reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			fields = jsonFields(t)
+		}
+		for k, item := range val {
+			var ft reflect.Type
+			switch {
+			case fields != nil:
+				ft = fields[k]
+			case t != nil && t.Kind() == reflect.Map:
+				ft = t.Elem()
+			}
+			val[k] = resolveValue(item, ft)
+		}
+		return val
+	case []interface{}:
+		var et reflect.Type
+		if t != nil && t.Kind() == reflect.Slice {
+			et = t.Elem()
+		}
+		for i, item := range val {
+			val[i] = resolveValue(item, et)
+		}
+		return val
+	case plainScalar:
+		return resolveScalar(string(val), t)
+	case int64:
+		if t != nil && (t.Kind() == reflect.String || t.Kind() == reflect.Struct) {
+			return strconv.FormatInt(val, 10)
+		}
+		return val
+	default:
+		return val
+	}
+}
+
+func resolveScalar(s string, t reflect.Type) interface{} {
+	if s == "~" || s == "null" || s == "Null" || s == "NULL" {
+		return nil
+	}
+	kind := reflect.Invalid
+	if t != nil {
+		kind = t.Kind()
+	}
+	switch kind {
+	case reflect.String, reflect.Struct:
+		return s // 结构体位置上的标量只能是字符串简写，如 node_ids 中的节点ID
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		if n, err := strconv.ParseInt(strings.ReplaceAll(s, "_", ""), 0, 64); err == nil {
+			return n
+		}
+		return s // 交给 json.Unmarshal 报类型错误
+	case reflect.Bool:
+		if b, ok := yamlBool(s); ok {
+			return b
+		}
+		return s
+	}
+	if b, ok := yamlBool(s); ok {
+		return b
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+func yamlBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on":
+		return true, true
+	case "false", "no", "off":
+		return false, true
+	}
+	return false, false
+}
+
+// ---- YAML ----
+
+// parseYAML 解析 YAML 配置文件；未加引号的标量保留原文（plainScalar），由 resolveValue 按字段类型转换
+func parseYAML(src string) (interface{}, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return nil, nil // 空文件
+	}
+	return yamlValue(doc.Content[0])
+}
+
+// yamlValue 将 YAML 节点转换为与 JSON 解析结果相同结构的值
+func yamlValue(n *yaml.Node) (interface{}, error) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		obj := make(map[string]interface{}, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i]
+			if k.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("yaml 第 %d 行: 键必须是字符串", k.Line)
+			}
+			if k.Tag == "!!merge" {
+				return nil, fmt.Errorf("yaml 第 %d 行: 不支持合并键 <<", k.Line)
+			}
+			if _, dup := obj[k.Value]; dup {
+				return nil, fmt.Errorf("yaml 第 %d 行: 重复的键 %q", k.Line, k.Value)
+			}
+			v, err := yamlValue(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			obj[k.Value] = v
+		}
+		return obj, nil
+	case yaml.SequenceNode:
+		seq := make([]interface{}, 0, len(n.Content))
+		for _, item := range n.Content {
+			v, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+		}
+		return seq, nil
+	case yaml.AliasNode:
+		return yamlValue(n.Alias)
+	case yaml.ScalarNode:
+		if n.Style == 0 || n.Style == yaml.FlowStyle {
+			if n.ShortTag() == "!!null" {
+				return nil, nil
+			}
+			return plainScalar(n.Value), nil
+		}
+		return n.Value, nil // 加引号或多行字符串
+	}
+	return nil, fmt.Errorf("yaml 第 %d 行: 无法识别的节点", n.Line)
+}
+
+// ---- TOML ----
+
+// parseTOML 解析 TOML 配置文件；表数组（[[node_ids]]）转换为对象数组
+func parseTOML(src string) (interface{}, error) {
+	var tree map[string]interface{}
+	if _, err := toml.Decode(src, &tree); err != nil {
+		return nil, err
+	}
+	return tomlValue(tree), nil
+}
+
+// tomlValue 将表数组 []map[string]interface{} 统一为 []interface{}，与 JSON 解析结果结构一致
+func tomlValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = tomlValue(item)
+		}
+		return val
+	case []map[string]interface{}:
+		seq := make([]interface{}, len(val))
+		for i, item := range val {
+			seq[i] = tomlValue(item)
+		}
+		return seq
+	case []interface{}:
+		for i, item := range val {
+			val[i] = tomlValue(item)
+		}
+		return val
+	}
+	return v
+}
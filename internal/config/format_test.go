@@ -0,0 +1,264 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type obj = map[string]interface{}
+type seq = []interface{}
+
+// TestParseYAML 测试 YAML 子集的注释、引号、嵌套映射与序列
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		{
+			name: "行尾注释",
+			src:  "a: 1 # 注释\n# 整行注释\nb: x",
+			want: obj{"a": plainScalar("1"), "b": plainScalar("x")},
+		},
+		{
+			name: "普通标量中的撇号不开始字符串",
+			src:  "location: Xi'an # city\nname: it's \"ok\" # c",
+			want: obj{"location": plainScalar("Xi'an"), "name": plainScalar("it's \"ok\"")},
+		},
+		{
+			name: "# 紧跟非空白字符不是注释",
+			src:  "url: http://host/#frag",
+			want: obj{"url": plainScalar("http://host/#frag")},
+		},
+		{
+			name: "引号字符串中的 #",
+			src:  "a: \"x # y\" # c\nb: 'it''s # z'\nc: \"q\\\"# w\"",
+			want: obj{"a": "x # y", "b": "it's # z", "c": "q\"# w"},
+		},
+		{
+			name: "引号键",
+			src:  "\"a b\": 1\n'c': d",
+			want: obj{"a b": plainScalar("1"), "c": plainScalar("d")},
+		},
+		{
+			name: "嵌套映射",
+			src:  "api:\n  url: x\n  retry:\n    max: 3\nlog: y",
+			want: obj{"api": obj{"url": plainScalar("x"), "retry": obj{"max": plainScalar("3")}}, "log": plainScalar("y")},
+		},
+		{
+			name: "序列与映射项",
+			src:  "ids:\n- 1\n- '2' # c\nnodes:\n  - id: a\n    weight: 2\n  - id: b",
+			want: obj{
+				"ids":   seq{plainScalar("1"), "2"},
+				"nodes": seq{obj{"id": plainScalar("a"), "weight": plainScalar("2")}, obj{"id": plainScalar("b")}},
+			},
+		},
+		{
+			name: "流式集合",
+			src:  "a: [1, 'x, y', {k: v}] # c\nb: {}",
+			want: obj{"a": seq{plainScalar("1"), "x, y", obj{"k": plainScalar("v")}}, "b": obj{}},
+		},
+		{
+			name: "空值",
+			src:  "a:\nb: 1",
+			want: obj{"a": nil, "b": plainScalar("1")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML(tt.src)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("解析结果 = %#v, 期望 %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseYAMLError 测试格式错误的 YAML 报错并指出行号
+func TestParseYAMLError(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{"tab 缩进", "a:\n\tb: 1", "line 2"},
+		{"重复的键", "a: 1\na: 2", "第 2 行: 重复的键"},
+		{"缩进错误", "a: 1\n   b: 2", "line 2"},
+		{"序列后的键", "- a\nb: 1", "line 1"},
+		{"未闭合的引号", "a: 'x", "yaml"},
+		{"合并键", "base: &b {x: 1}\nnode:\n  <<: *b", "不支持合并键"},
+		{"非字符串键", "[a]: 1", "键必须是字符串"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("错误 = %v, 期望包含 %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestParseTOML 测试 TOML 子集的注释、引号、表与数组
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		{
+			name: "注释与基本类型",
+			src:  "# 注释\na = 1 # c\nb = 1_000\nc = 0.5\nd = true\ne = \"x # y\"\nf = 'it#s'",
+			want: obj{"a": int64(1), "b": int64(1000), "c": 0.5, "d": true, "e": "x # y", "f": "it#s"},
+		},
+		{
+			name: "表与点号键",
+			src:  "top = 1\n[api]\nurl = \"x\"\nretry.max = 3\n[api.tls]\nverify = false",
+			want: obj{"top": int64(1), "api": obj{"url": "x", "retry": obj{"max": int64(3)}, "tls": obj{"verify": false}}},
+		},
+		{
+			name: "引号键",
+			src:  "[profiles.\"my-env\"]\n\"a.b\" = 1",
+			want: obj{"profiles": obj{"my-env": obj{"a.b": int64(1)}}},
+		},
+		{
+			name: "多行数组与内联表",
+			src:  "ids = [\n  \"1\", # 第一个\n  \"2\",\n]\nnode = { id = \"a\", weight = 2 }",
+			want: obj{"ids": seq{"1", "2"}, "node": obj{"id": "a", "weight": int64(2)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(tt.src)
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("解析结果 = %#v, 期望 %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseTOMLError 测试格式错误的 TOML 报错并指出行号
+func TestParseTOMLError(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{"表头缺少 ]", "[api", "line 1"},
+		{"缺少等号", "a", "line 1"},
+		{"未加引号的字符串", "a = xyz", "line 1"},
+		{"重复的键", "a = 1\na = 2", "line 2"},
+		{"键已定义为非表", "a = 1\n[a.b]", "line 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("错误 = %v, 期望包含 %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// TestDecodeFileScalars 测试 YAML 未加引号的标量按字段类型转换
+func TestDecodeFileScalars(t *testing.T) {
+	got, err := decodeFile("c.yaml", []byte("node_ids: [12739613, \"007\", {id: 5, enabled: off}]\nprover_workers: 0x10\nuser_id: ~\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"node_ids":       []interface{}{"12739613", "007", map[string]interface{}{"id": "5", "enabled": false}},
+		"prover_workers": int64(16),
+		"user_id":        nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("解析结果 = %#v, 期望 %#v", got, want)
+	}
+}
+
+// TestDecodeFile 测试 YAML / TOML 的值按 Config 字段类型转换，结果与等价的 JSON 配置相同
+func TestDecodeFile(t *testing.T) {
+	want := `{"node_ids": [{"id": "12739613"}, {"id": "12739614", "weight": 3}], "prover_workers": 9,
+		"queue": {"backend": "disk", "program_ttl": {"fib_input": 600}}, "profiles": {"dev": {"api_url": "http://x"}}}`
+	tests := []struct {
+		name string
+		path string
+		src  string
+	}{
+		{
+			name: "YAML",
+			path: "config.yaml",
+			src: `node_ids:
+  - id: 12739613      # 未加引号的数字按字段类型转换为字符串
+  - id: 12739614
+    weight: 3
+prover_workers: 9
+queue:
+  backend: disk
+  program_ttl: {fib_input: 600}
+profiles:
+  dev:
+    api_url: http://x
+`,
+		},
+		{
+			name: "YAML 锚点",
+			path: "config.yml",
+			src: `workers: &w 9
+node_ids: [{id: "12739613"}, {id: "12739614", weight: 3}]
+prover_workers: *w
+queue: {backend: disk, program_ttl: {fib_input: 600}}
+profiles: {dev: {api_url: "http://x"}}
+`,
+		},
+		{
+			name: "TOML 表数组",
+			path: "config.toml",
+			src: `prover_workers = 9
+
+[[node_ids]]
+id = "12739613"
+
+[[node_ids]]
+id = "12739614"
+weight = 3
+
+[queue]
+backend = "disk"
+program_ttl = { fib_input = 600 }
+
+[profiles.dev]
+api_url = "http://x"
+`,
+		},
+	}
+	var expected map[string]interface{}
+	if err := json.Unmarshal([]byte(want), &expected); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeFile(tt.path, []byte(tt.src))
+			if tt.name == "YAML 锚点" {
+				delete(got, "workers") // 未知字段由 unknownFields 报告，这里只比较结构
+			}
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			var normalized map[string]interface{}
+			json.Unmarshal(gotJSON, &normalized)
+			if !reflect.DeepEqual(normalized, expected) {
+				t.Errorf("解析结果 = %s, 期望 %s", gotJSON, want)
+			}
+		})
+	}
+}
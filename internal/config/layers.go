@@ -0,0 +1,330 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 配置分层加载，优先级从低到高: 默认值 < 配置文件 < 环境变量 NEXUS_* < 命令行参数
+//
+// 每个字段按 JSON 路径命名，如 rate_limit.window 对应环境变量 NEXUS_RATE_LIMIT_WINDOW
+// 和命令行参数 -rate-limit-window。数组字段（node_ids）用逗号分隔，profiles 为 JSON 对象。
+
+const ENV_PREFIX = "NEXUS_" // 配置环境变量前缀
+
+// 配置值来源
+const (
+	SOURCE_DEFAULT = "默认值"
+	SOURCE_FILE    = "配置文件"
+	SOURCE_ENV     = "环境变量"
+	SOURCE_FLAG    = "命令行"
+)
+
+// LoadOptions 分层加载选项
+type LoadOptions struct {
+	Path  string                      // 配置文件路径（JSON / YAML / TOML），为空时不读取文件
+	Env   func(string) (string, bool) // 环境变量查询，默认 os.LookupEnv
+	Flags map[string]string           // 命令行参数，按字段路径，见 FlagBinding.Values
+}
+
+// Field 可覆盖的配置字段
+type Field struct {
+	Path string       // JSON 路径，如 rate_limit.window
+	Type reflect.Type // 字段类型
+}
+
+// EnvName 字段对应的环境变量名
+func (f Field) EnvName() string {
+	return ENV_PREFIX + strings.ToUpper(strings.ReplaceAll(f.Path, ".", "_"))
+}
+
+// FlagName 字段对应的命令行参数名（不含 -）
+func (f Field) FlagName() string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(f.Path)
+}
+
+// Fields Config 的全部叶子字段，按定义顺序；嵌套结构体展开，数组和 map 作为整体
+func Fields() []Field {
+	var fields []Field
+	collectFields(&fields, "", reflect.TypeOf(Config{}))
+	return fields
+}
+
+func collectFields(fields *[]Field, prefix string, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || name == "" || f.PkgPath != "" {
+			continue
+		}
+		path := joinField(prefix, name)
+		if f.Type.Kind() == reflect.Struct {
+			collectFields(fields, path, f.Type)
+			continue
+		}
+		*fields = append(*fields, Field{Path: path, Type: f.Type})
+	}
+}
+
+// Load 按 默认值 < 配置文件 < 环境变量 < 命令行参数 加载配置并校验，
+// 每个字段的来源可通过 Config.Source 查询
+func Load(opts LoadOptions) (*Config, error) {
+	if opts.Env == nil {
+		opts.Env = os.LookupEnv
+	}
+
+	tree := make(map[string]interface{})
+	if opts.Path != "" {
+		data, err := os.ReadFile(opts.Path)
+		if err != nil {
+			return nil, err
+		}
+		if tree, err = decodeFile(opts.Path, data); err != nil {
+			return nil, fmt.Errorf("解析配置文件 %s 失败: %v", opts.Path, err)
+		}
+	}
+
+	sources := make(map[string]string)
+	var overrideErrs []FieldError
+	for _, f := range Fields() {
+		if _, ok := lookupPath(tree, f.Path); ok {
+			sources[f.Path] = SOURCE_FILE + " " + opts.Path
+		}
+		layers := []override{envOverride(opts.Env, f), flagOverride(opts.Flags, f)}
+		for _, l := range layers {
+			if !l.ok {
+				continue
+			}
+			v, err := parseOverride(f, l.raw)
+			if err != nil {
+				overrideErrs = append(overrideErrs, FieldError{Field: f.Path, Message: fmt.Sprintf("%s: %v", l.source, err)})
+				continue
+			}
+			setPath(tree, f.Path, v)
+			sources[f.Path] = l.source
+		}
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := decodeConfig(data)
+	if cfg != nil {
+		cfg.sources = sources
+	}
+	if err != nil || len(overrideErrs) > 0 {
+		verr := &ValidationError{Errors: overrideErrs}
+		if ve, ok := err.(*ValidationError); ok {
+			verr.Errors = append(verr.Errors, annotateSources(ve.Errors, sources)...)
+		} else if err != nil {
+			return nil, err
+		}
+		return cfg, verr
+	}
+	return cfg, nil
+}
+
+// override 环境变量或命令行中的字段值
+type override struct {
+	raw    string
+	ok     bool
+	source string
+}
+
+func envOverride(env func(string) (string, bool), f Field) override {
+	raw, ok := env(f.EnvName())
+	return override{raw: raw, ok: ok, source: SOURCE_ENV + " " + f.EnvName()}
+}
+
+func flagOverride(flags map[string]string, f Field) override {
+	raw, ok := flags[f.Path]
+	return override{raw: raw, ok: ok, source: SOURCE_FLAG + " -" + f.FlagName()}
+}
+
+// parseOverride 将环境变量或命令行中的字符串转换为字段类型
+func parseOverride(f Field, raw string) (interface{}, error) {
+	switch f.Type.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("应为整数: %q", raw)
+		}
+		return n, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("应为 true/false: %q", raw)
+		}
+		return b, nil
+	case reflect.Slice:
+		if trimmed := strings.TrimSpace(raw); strings.HasPrefix(trimmed, "[") {
+			var v interface{}
+			if err := json.Unmarshal([]byte(trimmed), &v); err != nil {
+				return nil, fmt.Errorf("应为 JSON 数组: %v", err)
+			}
+			return v, nil
+		}
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	default:
+		var v interface{}
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			return nil, fmt.Errorf("应为 JSON: %v", err)
+		}
+		return v, nil
+	}
+}
+
+// annotateSources 为来自环境变量或命令行的字段错误标注来源，便于定位
+func annotateSources(errs []FieldError, sources map[string]string) []FieldError {
+	for i, fe := range errs {
+		if src := sources[fieldRoot(fe.Field)]; strings.HasPrefix(src, SOURCE_ENV) || strings.HasPrefix(src, SOURCE_FLAG) {
+			errs[i].Message += " (来自" + src + ")"
+		}
+	}
+	return errs
+}
+
+// fieldRoot 错误路径所属的字段，如 node_ids[1] => node_ids，profiles.beta.base_url => profiles
+func fieldRoot(path string) string {
+	best := ""
+	for _, f := range Fields() {
+		if (path == f.Path || strings.HasPrefix(path, f.Path+".") || strings.HasPrefix(path, f.Path+"[")) && len(f.Path) > len(best) {
+			best = f.Path
+		}
+	}
+	return best
+}
+
+func lookupPath(tree map[string]interface{}, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	var cur interface{} = tree
+	for _, p := range parts {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[p]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func setPath(tree map[string]interface{}, path string, v interface{}) {
+	parts := strings.Split(path, ".")
+	obj := tree
+	for _, p := range parts[:len(parts)-1] {
+		child, ok := obj[p].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			obj[p] = child
+		}
+		obj = child
+	}
+	obj[parts[len(parts)-1]] = v
+}
+
+// Source 字段值的来源，如 "环境变量 NEXUS_PROVER_WORKERS"，未设置时为默认值
+func (c *Config) Source(path string) string {
+	if src, ok := c.sources[path]; ok {
+		return src
+	}
+	return SOURCE_DEFAULT
+}
+
+// EffectiveValue 生效的配置值及来源
+type EffectiveValue struct {
+	Field  Field
+	Value  string // JSON 编码的值
+	Source string
+}
+
+// Effective 列出全部字段生效的值及来源
+func (c *Config) Effective() []EffectiveValue {
+	root := reflect.ValueOf(c).Elem()
+	var out []EffectiveValue
+	for _, f := range Fields() {
+		v := root
+		for _, name := range strings.Split(f.Path, ".") {
+			v = v.Field(fieldIndex(v.Type(), name))
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			data = []byte(fmt.Sprintf("%v", v.Interface()))
+		}
+		out = append(out, EffectiveValue{Field: f, Value: string(data), Source: c.Source(f.Path)})
+	}
+	return out
+}
+
+func fieldIndex(t reflect.Type, jsonName string) int {
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == jsonName {
+			return i
+		}
+	}
+	panic("config: unknown field " + jsonName)
+}
+
+// FlagBinding 为每个配置字段注册命令行参数，如 -prover-workers、-rate-limit-window
+type FlagBinding struct {
+	fs    *flag.FlagSet
+	paths map[string]string // 参数名 => 字段路径
+}
+
+// BindFlags 在 fs 上注册全部配置字段参数，-env 为 -environment 的简写
+func BindFlags(fs *flag.FlagSet) *FlagBinding {
+	b := &FlagBinding{fs: fs, paths: make(map[string]string)}
+	for _, f := range Fields() {
+		usage := fmt.Sprintf("覆盖配置 %s (环境变量 %s)", f.Path, f.EnvName())
+		switch f.Type.Kind() {
+		case reflect.Slice:
+			usage += "，逗号分隔或 JSON 数组"
+		case reflect.Map:
+			usage += "，JSON 对象"
+		}
+		fs.String(f.FlagName(), "", usage)
+		b.paths[f.FlagName()] = f.Path
+	}
+	fs.String("env", "", "编排服务环境: beta / prod / local 或配置文件中自定义的环境，同 -environment")
+	b.paths["env"] = "environment"
+	return b
+}
+
+// Values 命令行中显式设置的配置字段，按字段路径
+func (b *FlagBinding) Values() map[string]string {
+	values := make(map[string]string)
+	b.fs.Visit(func(f *flag.Flag) {
+		if path, ok := b.paths[f.Name]; ok {
+			values[path] = f.Value.String()
+		}
+	})
+	return values
+}
+
+// ChangedFields 两份配置中值不同的字段路径，按定义顺序
+func ChangedFields(old, new *Config) []string {
+	before := old.Effective()
+	after := new.Effective()
+	var changed []string
+	for i := range before {
+		if before[i].Value != after[i].Value {
+			changed = append(changed, before[i].Field.Path)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// envMap 模拟环境变量
+func envMap(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadPrecedence 测试 默认值 < 配置文件 < 环境变量 < 命令行参数 的覆盖顺序和每个字段的来源
+func TestLoadPrecedence(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+node_ids: ["1001"]
+prover_workers: 2
+batch_size: 5
+rate_limit:
+  window: 30
+`)
+	cfg, err := Load(LoadOptions{
+		Path:  path,
+		Env:   envMap(map[string]string{"NEXUS_BATCH_SIZE": "7", "NEXUS_PROVER_WORKERS": "3", "NEXUS_RATE_LIMIT_WINDOW": "45"}),
+		Flags: map[string]string{"prover_workers": "4"},
+	})
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	tests := []struct {
+		path   string
+		value  interface{}
+		source string
+	}{
+		{"node_ids", []string{"1001"}, SOURCE_FILE + " " + path},
+		{"prover_workers", 4, SOURCE_FLAG + " -prover-workers"},
+		{"batch_size", 7, SOURCE_ENV + " NEXUS_BATCH_SIZE"},
+		{"rate_limit.window", 45, SOURCE_ENV + " NEXUS_RATE_LIMIT_WINDOW"},
+		{"task_fetch_interval", DEFAULT_TASK_FETCH_INTERVAL, SOURCE_DEFAULT},
+	}
+	values := map[string]interface{}{
+		"node_ids":            cfg.NodeIDs(),
+		"prover_workers":      cfg.ProverWorkers,
+		"batch_size":          cfg.BatchSize,
+		"rate_limit.window":   cfg.RateLimit.Window,
+		"task_fetch_interval": cfg.TaskFetchInterval,
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := values[tt.path]; !reflect.DeepEqual(got, tt.value) {
+				t.Errorf("值 = %v, 期望 %v", got, tt.value)
+			}
+			if got := cfg.Source(tt.path); got != tt.source {
+				t.Errorf("来源 = %q, 期望 %q", got, tt.source)
+			}
+		})
+	}
+}
+
+// TestLoadWithoutFile 测试不读取配置文件时只使用环境变量和命令行参数
+func TestLoadWithoutFile(t *testing.T) {
+	cfg, err := Load(LoadOptions{
+		Env:   envMap(map[string]string{"NEXUS_NODE_IDS": "1001, 1002", "NEXUS_PROVER_WORKERS": "1"}),
+		Flags: map[string]string{"node_ids": `[{"id": "2001", "weight": 2}]`},
+	})
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if len(cfg.Nodes) != 1 || cfg.Nodes[0].ID != "2001" || cfg.Nodes[0].Weight != 2 {
+		t.Errorf("命令行的 node_ids 应覆盖环境变量: %+v", cfg.Nodes)
+	}
+}
+
+// TestLoadOverrideErrors 测试环境变量和命令行中无法转换的值与校验错误都标注来源
+func TestLoadOverrideErrors(t *testing.T) {
+	_, err := Load(LoadOptions{
+		Env:   envMap(map[string]string{"NEXUS_NODE_IDS": "1001", "NEXUS_BATCH_SIZE": "many", "NEXUS_PROVER_WORKERS": "-1"}),
+		Flags: map[string]string{"rate_limit.window": "-5"},
+	})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("错误 = %v, 期望 *ValidationError", err)
+	}
+	want := map[string]string{
+		"batch_size":        "环境变量 NEXUS_BATCH_SIZE: 应为整数",
+		"prover_workers":    "(来自环境变量 NEXUS_PROVER_WORKERS)",
+		"rate_limit.window": "(来自命令行 -rate-limit-window)",
+	}
+	for field, msg := range want {
+		found := false
+		for _, fe := range verr.Errors {
+			if fe.Field == field && strings.Contains(fe.Message, msg) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("缺少错误 %s: %s, 实际: %v", field, msg, err)
+		}
+	}
+}
+
+// TestParseOverride 测试环境变量和命令行字符串按字段类型转换
+func TestParseOverride(t *testing.T) {
+	fields := make(map[string]Field)
+	for _, f := range Fields() {
+		fields[f.Path] = f
+	}
+	tests := []struct {
+		path    string
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{"environment", " beta ", " beta ", false},
+		{"prover_workers", " 4 ", 4, false},
+		{"prover_workers", "4.5", nil, true},
+		{"node_ids", "1001, ,1002,", []string{"1001", "1002"}, false},
+		{"node_ids", `["1001", {"id": "1002"}]`, []interface{}{"1001", map[string]interface{}{"id": "1002"}}, false},
+		{"node_ids", `[1001`, nil, true},
+		{"profiles", `{"dev": {"api_url": "http://x"}}`, map[string]interface{}{"dev": map[string]interface{}{"api_url": "http://x"}}, false},
+		{"profiles", `dev`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+"="+tt.raw, func(t *testing.T) {
+			f, ok := fields[tt.path]
+			if !ok {
+				t.Fatalf("没有字段 %s", tt.path)
+			}
+			got, err := parseOverride(f, tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("错误 = %v, 期望出错 %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("结果 = %#v, 期望 %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFieldNames 测试字段对应的环境变量名和命令行参数名，-env 为 -environment 的简写
+func TestFieldNames(t *testing.T) {
+	f := Field{Path: "rate_limit.node_requests"}
+	if f.EnvName() != "NEXUS_RATE_LIMIT_NODE_REQUESTS" || f.FlagName() != "rate-limit-node-requests" {
+		t.Errorf("EnvName = %s, FlagName = %s", f.EnvName(), f.FlagName())
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	binding := BindFlags(fs)
+	if err := fs.Parse([]string{"-env", "beta", "-rate-limit-window", "30"}); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"environment": "beta", "rate_limit.window": "30"}
+	if got := binding.Values(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Values = %v, 期望 %v", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// NodeConfig node_ids 中的一个节点：可以是字符串（只有节点ID），也可以是带节点级覆盖的对象，
+// 对象中未填写的字段沿用全局配置
+type NodeConfig struct {
+	ID                string `json:"id"`
+	Enabled           *bool  `json:"enabled,omitempty"`             // 是否获取任务，默认 true；false 时保留节点（密钥、签名白名单）但暂停获取
+	TaskFetchInterval int    `json:"task_fetch_interval,omitempty"` // 获取任务间隔（秒），默认全局 task_fetch_interval
+	BatchSize         int    `json:"batch_size,omitempty"`          // 每次获取新任务的数量，默认全局 batch_size
+	Weight            int    `json:"weight,omitempty"`              // 份额权重，默认1；队列空间不足以让所有节点取满一批时按权重分配本轮获取的任务数
+	KeyFile           string `json:"key_file,omitempty"`            // 节点密钥文件，默认 <key_dir>/<id>.json
+	TelemetryLocation string `json:"telemetry_location,omitempty"`  // 遥测上报的地理位置，默认全局 telemetry_location
+}
+
+// nodeConfigObject 避免 UnmarshalJSON / MarshalJSON 递归
+type nodeConfigObject NodeConfig
+
+// UnmarshalJSON 支持 "12345" 和 {"id": "12345", ...} 两种写法
+func (n *NodeConfig) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		*n = NodeConfig{}
+		return json.Unmarshal(trimmed, &n.ID)
+	}
+	var obj nodeConfigObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*n = NodeConfig(obj)
+	return nil
+}
+
+// MarshalJSON 没有节点级覆盖时输出为字符串，与原有格式保持一致
+func (n NodeConfig) MarshalJSON() ([]byte, error) {
+	if n == (NodeConfig{ID: n.ID}) {
+		return json.Marshal(n.ID)
+	}
+	return json.Marshal(nodeConfigObject(n))
+}
+
+// IsEnabled 节点是否获取任务
+func (n NodeConfig) IsEnabled() bool {
+	return n.Enabled == nil || *n.Enabled
+}
+
+// WithDefaults 用全局配置填充未覆盖的字段
+func (n NodeConfig) WithDefaults(c *Config) NodeConfig {
+	if n.TaskFetchInterval <= 0 {
+		n.TaskFetchInterval = c.TaskFetchInterval
+	}
+	if n.BatchSize <= 0 {
+		n.BatchSize = c.BatchSize
+	}
+	if n.Weight <= 0 {
+		n.Weight = 1
+	}
+	if n.TelemetryLocation == "" {
+		n.TelemetryLocation = c.TelemetryLocation
+	}
+	return n
+}
+
+// NodeIDs 配置的全部节点ID（包括暂停的节点），按配置顺序
+func (c *Config) NodeIDs() []string {
+	ids := make([]string, 0, len(c.Nodes))
+	for _, n := range c.Nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+// Node 按ID查找配置的节点，未配置（如自动发现的节点）时返回只有ID的节点
+func (c *Config) Node(id string) NodeConfig {
+	for _, n := range c.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	return NodeConfig{ID: id}
+}
+
+// KeyFiles 配置了 key_file 的节点: 节点ID => 密钥文件
+func (c *Config) KeyFiles() map[string]string {
+	files := make(map[string]string)
+	for _, n := range c.Nodes {
+		if n.KeyFile != "" {
+			files[n.ID] = n.KeyFile
+		}
+	}
+	return files
+}
+
+// NodeWeights 配置了 weight 的节点: 节点ID => 权重
+func (c *Config) NodeWeights() map[string]int {
+	weights := make(map[string]int)
+	for _, n := range c.Nodes {
+		if n.Weight > 0 {
+			weights[n.ID] = n.Weight
+		}
+	}
+	return weights
+}
+
+// NodeLocations 配置了 telemetry_location 的节点: 节点ID => 地理位置
+func (c *Config) NodeLocations() map[string]string {
+	locations := make(map[string]string)
+	for _, n := range c.Nodes {
+		if n.TelemetryLocation != "" {
+			locations[n.ID] = n.TelemetryLocation
+		}
+	}
+	return locations
+}
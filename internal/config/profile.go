@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Profile 编排服务(orchestrator)环境配置
+type Profile struct {
+	BaseURL             string `json:"base_url"`              // 服务地址，如 https://beta.orchestrator.nexus.xyz
+	APIVersion          string `json:"api_version"`           // API版本前缀，如 v3
+	RequestTimeout      int    `json:"request_timeout"`       // 获取任务等请求超时（秒）
+	SubmitTimeout       int    `json:"submit_timeout"`        // 提交证明请求超时（秒）
+	TLSHandshakeTimeout int    `json:"tls_handshake_timeout"` // TLS握手超时（秒）
+	IdleConnTimeout     int    `json:"idle_conn_timeout"`     // 空闲连接超时（秒）
+	InsecureSkipVerify  bool   `json:"insecure_skip_verify"`  // 跳过TLS证书校验，仅用于本地测试
+	CACertFile          string `json:"ca_cert_file"`          // 自定义CA证书文件（PEM）
+}
+
+// 内置环境名称
+const (
+	ENV_BETA  = "beta"
+	ENV_PROD  = "prod"
+	ENV_LOCAL = "local"
+
+	DEFAULT_ENVIRONMENT = ENV_BETA
+)
+
+// builtinProfiles 内置环境配置，可在配置文件 profiles 中按名称覆盖
+var builtinProfiles = map[string]Profile{
+	ENV_BETA: {
+		BaseURL:             "https://beta.orchestrator.nexus.xyz",
+		APIVersion:          "v3",
+		RequestTimeout:      30,
+		SubmitTimeout:       60,
+		TLSHandshakeTimeout: 10,
+		IdleConnTimeout:     90,
+	},
+	ENV_PROD: {
+		BaseURL:             "https://production.orchestrator.nexus.xyz",
+		APIVersion:          "v3",
+		RequestTimeout:      30,
+		SubmitTimeout:       60,
+		TLSHandshakeTimeout: 10,
+		IdleConnTimeout:     90,
+	},
+	ENV_LOCAL: {
+		BaseURL:             "http://127.0.0.1:50505",
+		APIVersion:          "v3",
+		RequestTimeout:      10,
+		SubmitTimeout:       20,
+		TLSHandshakeTimeout: 5,
+		IdleConnTimeout:     30,
+	},
+}
+
+// URL 拼接完整的API地址，如 URL("tasks", "submit") => https://host/v3/tasks/submit
+func (p Profile) URL(elem ...string) string {
+	parts := []string{strings.TrimRight(p.BaseURL, "/")}
+	if v := strings.Trim(p.APIVersion, "/"); v != "" {
+		parts = append(parts, v)
+	}
+	for _, e := range elem {
+		parts = append(parts, strings.Trim(e, "/"))
+	}
+	return strings.Join(parts, "/")
+}
+
+// merge 用 override 中的非零字段覆盖 p
+func (p Profile) merge(override Profile) Profile {
+	if override.BaseURL != "" {
+		p.BaseURL = override.BaseURL
+	}
+	if override.APIVersion != "" {
+		p.APIVersion = override.APIVersion
+	}
+	if override.RequestTimeout > 0 {
+		p.RequestTimeout = override.RequestTimeout
+	}
+	if override.SubmitTimeout > 0 {
+		p.SubmitTimeout = override.SubmitTimeout
+	}
+	if override.TLSHandshakeTimeout > 0 {
+		p.TLSHandshakeTimeout = override.TLSHandshakeTimeout
+	}
+	if override.IdleConnTimeout > 0 {
+		p.IdleConnTimeout = override.IdleConnTimeout
+	}
+	if override.InsecureSkipVerify {
+		p.InsecureSkipVerify = true
+	}
+	if override.CACertFile != "" {
+		p.CACertFile = override.CACertFile
+	}
+	return p
+}
+
+// ResolveProfile 根据名称解析环境配置（内置环境 + 配置文件覆盖）
+func (c *Config) ResolveProfile(name string) (Profile, error) {
+	if name == "" {
+		name = DEFAULT_ENVIRONMENT
+	}
+	base, builtin := builtinProfiles[name]
+	custom, ok := c.Profiles[name]
+	if !builtin && !ok {
+		return Profile{}, fmt.Errorf("未知环境: %s (可用: %s)", name, strings.Join(c.ProfileNames(), ", "))
+	}
+	if !builtin {
+		// 自定义环境未填写的版本前缀和超时沿用默认环境
+		base = builtinProfiles[DEFAULT_ENVIRONMENT]
+		base.BaseURL = ""
+	}
+	p := base.merge(custom)
+	if p.BaseURL == "" {
+		return Profile{}, fmt.Errorf("环境 %s 未配置 base_url", name)
+	}
+	return p, nil
+}
+
+// ActiveProfile 当前选中的环境配置
+func (c *Config) ActiveProfile() (Profile, error) {
+	return c.ResolveProfile(c.Environment)
+}
+
+// ProfileNames 所有可用环境名称
+func (c *Config) ProfileNames() []string {
+	seen := make(map[string]bool)
+	for name := range builtinProfiles {
+		seen[name] = true
+	}
+	for name := range c.Profiles {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
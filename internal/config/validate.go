@@ -0,0 +1,506 @@
+package config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FieldError 单个配置字段的校验错误，Field 为 JSON 路径，如 rate_limit.window、node_ids[1]
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError 配置校验失败，包含全部字段错误
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		lines = append(lines, fe.Error())
+	}
+	return fmt.Sprintf("配置校验失败 (%d 个错误):\n  %s", len(e.Errors), strings.Join(lines, "\n  "))
+}
+
+// validator 收集字段错误
+type validator struct {
+	errs []FieldError
+}
+
+func (v *validator) addf(field, format string, args ...interface{}) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+var (
+	uuidPattern   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	walletPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	nodeIDPattern = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Validate 校验已填充默认值的配置，返回 *ValidationError（包含全部字段错误）或 nil
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	seen := make(map[string]int, len(c.Nodes))
+	for i, n := range c.Nodes {
+		field := fmt.Sprintf("node_ids[%d]", i)
+		validateNode(v, field, n)
+		if !nodeIDPattern.MatchString(n.ID) {
+			v.addf(field, "无效的节点ID %q，应为数字", n.ID)
+			continue
+		}
+		if j, dup := seen[n.ID]; dup {
+			v.addf(field, "节点ID %s 与 node_ids[%d] 重复", n.ID, j)
+			continue
+		}
+		seen[n.ID] = i
+	}
+	if c.UserID != "" && !uuidPattern.MatchString(c.UserID) {
+		v.addf("user_id", "无效的UUID: %q", c.UserID)
+	}
+	if c.WalletAddress != "" {
+		if err := checkWalletAddress(c.WalletAddress); err != nil {
+			v.addf("wallet_address", "%v", err)
+		}
+	}
+
+	if c.RequestDelay < 0 {
+		v.addf("request_delay", "不能为负数: %d", c.RequestDelay)
+	}
+	if c.ProverWorkers <= 0 {
+		v.addf("prover_workers", "必须大于0: %d", c.ProverWorkers)
+	}
+	if c.ProverSubmitWaitSecond < 0 {
+		v.addf("prover_submit_wait_second", "不能为负数: %d", c.ProverSubmitWaitSecond)
+	}
+	if c.TaskQueueCapacity <= 0 {
+		v.addf("task_queue_capacity", "必须大于0: %d", c.TaskQueueCapacity)
+	}
+	if c.ExistingTasksMaxPages <= 0 {
+		v.addf("existing_tasks_max_pages", "必须大于0: %d", c.ExistingTasksMaxPages)
+	}
+	positives := []struct {
+		field string
+		value int
+	}{
+		{"batch_size", c.BatchSize},
+		{"max_consecutive_404s", c.MaxConsecutive404s},
+		{"task_fetch_interval", c.TaskFetchInterval},
+		{"queue_log_interval", c.QueueLogInterval},
+		{"retry_max_attempts", c.RetryMaxAttempts},
+		{"retry_queue_capacity", c.RetryQueueCapacity},
+		{"stats_interval", c.StatsInterval},
+		{"process_isolation.max_lifetime", c.ProcessIsolation.MaxLifetime},
+		{"process_isolation.max_restarts", c.ProcessIsolation.MaxRestarts},
+		{"queue.fsync_interval", c.Queue.FsyncInterval},
+		{"queue.compact_records", c.Queue.CompactRecords},
+		{"queue.dedup_ttl", c.Queue.DedupTTL},
+		{"queue.dedup_size", c.Queue.DedupSize},
+	}
+	for _, p := range positives {
+		if p.value <= 0 {
+			v.addf(p.field, "必须大于0: %d", p.value)
+		}
+	}
+	validateRetryBackoff(v, c.RetryBackoff)
+	switch c.NodeDiscovery {
+	case NODE_DISCOVERY_OFF, NODE_DISCOVERY_MERGE, NODE_DISCOVERY_REPLACE:
+	default:
+		v.addf("node_discovery", "未知模式 %q (可选: %s / %s / %s)",
+			c.NodeDiscovery, NODE_DISCOVERY_OFF, NODE_DISCOVERY_MERGE, NODE_DISCOVERY_REPLACE)
+	}
+	if c.NodeDiscoveryInterval <= 0 {
+		v.addf("node_discovery_interval", "必须大于0: %d", c.NodeDiscoveryInterval)
+	}
+
+	switch c.Queue.Backend {
+	case QUEUE_MEMORY, QUEUE_DISK:
+	default:
+		v.addf("queue.backend", "未知的队列存储 %q (可选: %s / %s)", c.Queue.Backend, QUEUE_MEMORY, QUEUE_DISK)
+	}
+	switch c.Queue.Fsync {
+	case QUEUE_FSYNC_ALWAYS, QUEUE_FSYNC_INTERVAL, QUEUE_FSYNC_NEVER:
+	default:
+		v.addf("queue.fsync", "未知的刷盘策略 %q (可选: %s / %s / %s)",
+			c.Queue.Fsync, QUEUE_FSYNC_ALWAYS, QUEUE_FSYNC_INTERVAL, QUEUE_FSYNC_NEVER)
+	}
+
+	if c.Queue.HighWater <= 0 || c.Queue.HighWater > 100 {
+		v.addf("queue.high_water", "必须在 1-100 之间（百分比）: %d", c.Queue.HighWater)
+	}
+	if c.Queue.LowWater < -1 || c.Queue.LowWater >= c.Queue.HighWater {
+		v.addf("queue.low_water", "必须小于 high_water（百分比）且大于0或为-1（队列清空后才恢复）: %d", c.Queue.LowWater)
+	}
+	if c.Queue.TaskTTL < 0 {
+		v.addf("queue.task_ttl", "不能为负数: %d", c.Queue.TaskTTL)
+	}
+	ttlPrograms := make([]string, 0, len(c.Queue.ProgramTTL))
+	for programID := range c.Queue.ProgramTTL {
+		ttlPrograms = append(ttlPrograms, programID)
+	}
+	sort.Strings(ttlPrograms)
+	for _, programID := range ttlPrograms {
+		if ttl := c.Queue.ProgramTTL[programID]; ttl < 0 {
+			v.addf("queue.program_ttl."+programID, "不能为负数: %d", ttl)
+		}
+	}
+	switch c.Queue.Scheduling {
+	case SCHEDULING_FIFO, SCHEDULING_PRIORITY:
+	default:
+		v.addf("queue.scheduling", "未知的调度方式 %q (可选: %s / %s)", c.Queue.Scheduling, SCHEDULING_FIFO, SCHEDULING_PRIORITY)
+	}
+	switch c.Queue.Fairness {
+	case FAIRNESS_OFF, FAIRNESS_ROUND_ROBIN, FAIRNESS_WEIGHTED:
+	default:
+		v.addf("queue.fairness", "未知的公平出队方式 %q (可选: %s / %s / %s)",
+			c.Queue.Fairness, FAIRNESS_OFF, FAIRNESS_ROUND_ROBIN, FAIRNESS_WEIGHTED)
+	}
+	weights := []struct {
+		field string
+		value float64
+	}{
+		{"queue.priority.age_weight", c.Queue.Priority.AgeWeight},
+		{"queue.priority.existing_bonus", c.Queue.Priority.ExistingBonus},
+		{"queue.priority.cost_weight", c.Queue.Priority.CostWeight},
+	}
+	for _, w := range weights {
+		if w.value < 0 && w.value != -1 {
+			v.addf(w.field, "必须大于0或为-1（不计入）: %v", w.value)
+		}
+	}
+	if c.Queue.Priority.DefaultCost < 0 {
+		v.addf("queue.priority.default_cost", "不能为负数: %d", c.Queue.Priority.DefaultCost)
+	}
+	programs := make([]string, 0, len(c.Queue.Priority.ProgramCosts))
+	for programID := range c.Queue.Priority.ProgramCosts {
+		programs = append(programs, programID)
+	}
+	sort.Strings(programs)
+	for _, programID := range programs {
+		if cost := c.Queue.Priority.ProgramCosts[programID]; cost < 0 {
+			v.addf("queue.priority.program_costs."+programID, "不能为负数: %d", cost)
+		}
+	}
+
+	switch c.Signer.Type {
+	case SIGNER_LOCAL:
+	case SIGNER_SOCKET:
+		if c.Signer.Socket == "" {
+			v.addf("signer.socket", "socket 签名方式必须配置 socket 路径")
+		}
+	default:
+		v.addf("signer.type", "未知的签名方式 %q (可选: %s / %s)", c.Signer.Type, SIGNER_LOCAL, SIGNER_SOCKET)
+	}
+
+	if c.RateLimit.GlobalRequests < -1 || c.RateLimit.GlobalRequests == 0 {
+		v.addf("rate_limit.global_requests", "必须大于0或为-1（不限制）: %d", c.RateLimit.GlobalRequests)
+	}
+	if c.RateLimit.NodeRequests < -1 || c.RateLimit.NodeRequests == 0 {
+		v.addf("rate_limit.node_requests", "必须大于0或为-1（不限制）: %d", c.RateLimit.NodeRequests)
+	}
+	if c.RateLimit.Window <= 0 {
+		v.addf("rate_limit.window", "必须大于0: %d", c.RateLimit.Window)
+	}
+	if c.RateLimit.Backoff <= 0 {
+		v.addf("rate_limit.backoff", "必须大于0: %d", c.RateLimit.Backoff)
+	}
+
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		validateProfile(v, "profiles."+name, c.Profiles[name])
+	}
+	if _, err := c.ActiveProfile(); err != nil {
+		v.addf("environment", "%v", err)
+	}
+
+	return v.err()
+}
+
+// validateRetryBackoff 校验提交重试退避策略
+func validateRetryBackoff(v *validator, r RetryBackoffConfig) {
+	if r.BaseDelay <= 0 {
+		v.addf("retry_backoff.base_delay", "必须大于0: %d", r.BaseDelay)
+	}
+	if r.MaxDelay < r.BaseDelay {
+		v.addf("retry_backoff.max_delay", "不能小于 base_delay: %d", r.MaxDelay)
+	}
+	if r.Multiplier < 1 {
+		v.addf("retry_backoff.multiplier", "不能小于1: %v", r.Multiplier)
+	}
+	if (r.Jitter < 0 || r.Jitter > 1) && r.Jitter != -1 {
+		v.addf("retry_backoff.jitter", "必须在 0-1 之间或为-1（不抖动）: %v", r.Jitter)
+	}
+	classes := make([]string, 0, len(r.ClassAttempts))
+	for class := range r.ClassAttempts {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		field := "retry_backoff.class_attempts." + class
+		switch class {
+		case RETRY_CLASS_RATE_LIMIT, RETRY_CLASS_SERVER, RETRY_CLASS_UNAUTHORIZED, RETRY_CLASS_CLIENT, RETRY_CLASS_NETWORK:
+		default:
+			v.addf(field, "未知的错误类型 (可选: %s / %s / %s / %s / %s)",
+				RETRY_CLASS_RATE_LIMIT, RETRY_CLASS_SERVER, RETRY_CLASS_UNAUTHORIZED, RETRY_CLASS_CLIENT, RETRY_CLASS_NETWORK)
+			continue
+		}
+		if n := r.ClassAttempts[class]; n < 0 {
+			v.addf(field, "不能为负数: %d", n)
+		}
+	}
+}
+
+// validateNode 校验节点级覆盖，0 表示沿用全局配置
+func validateNode(v *validator, field string, n NodeConfig) {
+	if n.TaskFetchInterval < 0 {
+		v.addf(field+".task_fetch_interval", "不能为负数: %d", n.TaskFetchInterval)
+	}
+	if n.BatchSize < 0 {
+		v.addf(field+".batch_size", "不能为负数: %d", n.BatchSize)
+	}
+	if n.Weight < 0 {
+		v.addf(field+".weight", "不能为负数: %d", n.Weight)
+	}
+}
+
+// validateProfile 校验配置文件中的环境覆盖项，未填写的字段沿用内置环境
+func validateProfile(v *validator, field string, p Profile) {
+	if p.BaseURL != "" {
+		u, err := url.Parse(p.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.addf(field+".base_url", "无效的地址 %q，应为 http(s)://host", p.BaseURL)
+		}
+	}
+	timeouts := []struct {
+		name  string
+		value int
+	}{
+		{"request_timeout", p.RequestTimeout},
+		{"submit_timeout", p.SubmitTimeout},
+		{"tls_handshake_timeout", p.TLSHandshakeTimeout},
+		{"idle_conn_timeout", p.IdleConnTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value < 0 {
+			v.addf(field+"."+t.name, "不能为负数: %d", t.value)
+		}
+	}
+}
+
+// checkWalletAddress 校验以太坊地址格式；大小写混合时按 EIP-55 校验和检查
+func checkWalletAddress(addr string) error {
+	if !walletPattern.MatchString(addr) {
+		return fmt.Errorf("无效的钱包地址 %q，应为 0x 开头的40位十六进制", addr)
+	}
+	hexPart := addr[2:]
+	if hexPart == strings.ToLower(hexPart) || hexPart == strings.ToUpper(hexPart) {
+		return nil // 全小写或全大写表示未使用校验和
+	}
+	if want := toChecksumAddress(addr); want != addr {
+		return fmt.Errorf("钱包地址校验和错误 %q (EIP-55 应为 %s)", addr, want)
+	}
+	return nil
+}
+
+// toChecksumAddress 按 EIP-55 生成带校验和的地址：Keccak-256(小写地址) 对应半字节 >= 8 的字母大写
+func toChecksumAddress(addr string) string {
+	lower := strings.ToLower(addr[2:])
+	hash := keccak256([]byte(lower))
+	hashHex := hex.EncodeToString(hash[:])
+	out := []byte(lower)
+	for i, ch := range out {
+		if ch >= 'a' && ch <= 'f' && hashHex[i] >= '8' {
+			out[i] = ch - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// decodeFields 按字段逐个解析 JSON，类型不匹配的字段记为 FieldError 并继续解析其他字段，
+// 而不是像 json.Unmarshal 那样只返回第一个类型错误
+func decodeFields(errs *[]FieldError, prefix string, data json.RawMessage, v reflect.Value) {
+	t := v.Type()
+	if string(bytes.TrimSpace(data)) == "null" {
+		return
+	}
+	leaf := reflect.PointerTo(t).Implements(jsonUnmarshalerType) ||
+		t.Kind() == reflect.Map && t.Key().Kind() != reflect.String ||
+		t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+	switch {
+	case leaf:
+	case t.Kind() == reflect.Struct:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			*errs = append(*errs, typeError(prefix, "对象", err))
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if name == "-" || f.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if raw, ok := obj[name]; ok {
+				decodeFields(errs, joinField(prefix, name), raw, v.Field(i))
+			}
+		}
+		return
+	case t.Kind() == reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			*errs = append(*errs, typeError(prefix, "数组", err))
+			return
+		}
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			decodeFields(errs, fmt.Sprintf("%s[%d]", prefix, i), item, slice.Index(i))
+		}
+		v.Set(slice)
+		return
+	case t.Kind() == reflect.Map:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(data, &obj); err != nil {
+			*errs = append(*errs, typeError(prefix, "对象", err))
+			return
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		m := reflect.MakeMapWithSize(t, len(obj))
+		for _, k := range keys {
+			elem := reflect.New(t.Elem()).Elem()
+			decodeFields(errs, joinField(prefix, k), obj[k], elem)
+			m.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), elem)
+		}
+		v.Set(m)
+		return
+	}
+	if err := json.Unmarshal(data, v.Addr().Interface()); err != nil {
+		*errs = append(*errs, typeError(prefix, "", err))
+	}
+}
+
+// typeError 将 JSON 解析错误转换为字段错误，如 "prover_workers": "4" 报告为 应为 int，实际为 string；
+// want 不为空时用于描述期望的对象或数组
+func typeError(field, want string, err error) FieldError {
+	te, ok := err.(*json.UnmarshalTypeError)
+	if !ok {
+		return FieldError{Field: field, Message: err.Error()}
+	}
+	if te.Field != "" {
+		field = joinField(field, te.Field)
+	} else if want != "" {
+		return FieldError{Field: field, Message: fmt.Sprintf("类型错误: 应为%s，实际为 %s", want, te.Value)}
+	}
+	return FieldError{Field: field, Message: fmt.Sprintf("类型错误: 应为 %s，实际为 %s", te.Type, te.Value)}
+}
+
+// unknownFields 找出配置文件中 Config 未定义的字段（拼写错误等），按 JSON 路径返回
+func unknownFields(data []byte) []FieldError {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	var errs []FieldError
+	walkUnknown(&errs, "", raw, reflect.TypeOf(Config{}))
+	return errs
+}
+
+func walkUnknown(errs *[]FieldError, prefix string, raw interface{}, t reflect.Type) {
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for _, k := range sortedKeys(obj) {
+			ft, ok := fields[k]
+			if !ok {
+				*errs = append(*errs, FieldError{Field: joinField(prefix, k), Message: "未知字段"})
+				continue
+			}
+			walkUnknown(errs, joinField(prefix, k), obj[k], ft)
+		}
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, k := range sortedKeys(obj) {
+			walkUnknown(errs, joinField(prefix, k), obj[k], t.Elem())
+		}
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			walkUnknown(errs, fmt.Sprintf("%s[%d]", prefix, i), item, t.Elem())
+		}
+	}
+}
+
+// jsonFields 结构体的 JSON 字段名到字段类型的映射
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinField(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestKeccak256 测试 Keccak-256 已知向量（以太坊使用的原始 Keccak 填充，不是 SHA3-256）
+func TestKeccak256(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"空输入", "", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"abc", "abc", "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+		{"句子", "The quick brown fox jumps over the lazy dog", "4d741b6f1eb29cb2a9b9911c82f56fa8d73b04959d3d9d222895df6c0b28aa15"},
+		{"正好一个分块，填充占满下一个分块", strings.Repeat("a", 136), "a6c4d403279fe3e0af03729caada8374b5ca54d8065329a3ebcaeb4b60aa386e"},
+		{"多个分块", strings.Repeat("a", 200), "96ea54061def936c4be90b518992fdc6f12f535068a256229aca54267b4d084d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keccak256([]byte(tt.input))
+			if hex.EncodeToString(got[:]) != tt.want {
+				t.Errorf("keccak256(%q) = %x, 期望 %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckWalletAddress 测试 EIP-55 规范中的地址：大小写混合时校验和必须正确，全小写或全大写不校验
+func TestCheckWalletAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr string
+	}{
+		{"全大写", "0x52908400098527886E0F7030069857D2E4169EE7", ""},
+		{"全大写2", "0x8617E340B3D01FA5F11F306F4090FD50E238070D", ""},
+		{"全小写", "0xde709f2102306220921060314715629080e2fb77", ""},
+		{"全小写2", "0x27b1fdb04752bbc536007a920d24acb045561c26", ""},
+		{"校验和1", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ""},
+		{"校验和2", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", ""},
+		{"校验和3", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", ""},
+		{"校验和4", "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb", ""},
+		{"校验和错误", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "校验和错误"},
+		{"长度错误", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", "无效的钱包地址"},
+		{"缺少0x", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "无效的钱包地址"},
+		{"非十六进制", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", "无效的钱包地址"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkWalletAddress(tt.addr)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("期望通过, 错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("错误 = %v, 期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+	if got := toChecksumAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"); got != "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed" {
+		t.Errorf("toChecksumAddress = %s", got)
+	}
+}
+
+// TestDecodeConfigValidation 测试配置校验返回的字段错误
+func TestDecodeConfigValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		field string // 为空表示校验通过
+		msg   string
+	}{
+		{"合法配置", `{"prover_workers": 2, "node_ids": ["1001", {"id": "1002", "weight": 2}], "user_id": "0b5e3c1a-7f2d-4c3b-9a1e-5d6f7a8b9c0d"}`, "", ""},
+		{"未知字段", `{"node_ids": ["1001"], "batch_sise": 3}`, "batch_sise", "未知字段"},
+		{"嵌套的未知字段", `{"node_ids": ["1001"], "queue": {"high_water": 80, "hgih_water": 90}}`, "queue.hgih_water", "未知字段"},
+		{"节点对象的未知字段", `{"node_ids": [{"id": "1001", "wieght": 2}]}`, "node_ids[0].wieght", "未知字段"},
+		{"重复的节点ID", `{"node_ids": ["1001", "1002", {"id": "1001"}]}`, "node_ids[2]", "与 node_ids[0] 重复"},
+		{"非数字节点ID", `{"node_ids": ["abc"]}`, "node_ids[0]", "应为数字"},
+		{"无效的UUID", `{"node_ids": ["1001"], "user_id": "0b5e3c1a-7f2d-4c3b-9a1e"}`, "user_id", "无效的UUID"},
+		{"UUID含非十六进制字符", `{"node_ids": ["1001"], "user_id": "0b5e3c1a-7f2d-4c3b-9a1e-5d6f7a8b9c0z"}`, "user_id", "无效的UUID"},
+		{"钱包地址校验和错误", `{"node_ids": ["1001"], "wallet_address": "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD"}`, "wallet_address", "校验和错误"},
+		{"整数字段写成字符串", `{"node_ids": ["1001"], "prover_workers": "4"}`, "prover_workers", "应为 int，实际为 string"},
+		{"嵌套字段类型错误", `{"node_ids": ["1001"], "prover_workers": 1, "rate_limit": {"window": true}}`, "rate_limit.window", "应为 int，实际为 bool"},
+		{"节点对象字段类型错误", `{"node_ids": ["1001", {"id": "1002", "weight": "2"}], "prover_workers": 1}`, "node_ids[1].weight", "应为 int，实际为 string"},
+		{"对象字段写成数组", `{"node_ids": ["1001"], "prover_workers": 1, "queue": []}`, "queue", "应为对象，实际为 array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeConfig([]byte(tt.json))
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("期望通过, 错误: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("错误 = %v, 期望 *ValidationError", err)
+			}
+			for _, fe := range verr.Errors {
+				if fe.Field == tt.field && strings.Contains(fe.Message, tt.msg) {
+					return
+				}
+			}
+			t.Errorf("错误 = %v, 期望 %s: %s", err, tt.field, tt.msg)
+		})
+	}
+}
+
+// TestDecodeConfigTypeErrors 测试多个字段类型错误全部报告，并继续校验其他字段
+func TestDecodeConfigTypeErrors(t *testing.T) {
+	cfg, err := decodeConfig([]byte(`{"node_ids": ["1001"], "prover_workers": "4", "batch_size": 2.5, "rate_limit": {"window": -1}}`))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("错误 = %v, 期望 *ValidationError", err)
+	}
+	want := map[string]bool{"prover_workers": true, "batch_size": true, "rate_limit.window": true}
+	got := make(map[string]int)
+	for _, fe := range verr.Errors {
+		got[fe.Field]++
+	}
+	for field := range want {
+		if got[field] != 1 {
+			t.Errorf("字段 %s 报告 %d 次, 期望 1 次: %v", field, got[field], err)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("错误 = %v, 期望只包含 %v", err, want)
+	}
+	if cfg == nil || len(cfg.Nodes) != 1 || cfg.Nodes[0].ID != "1001" {
+		t.Errorf("应返回已解析的其他字段: %+v", cfg)
+	}
+
+	if _, err := decodeConfig([]byte(`{"node_ids": [`)); err == nil || errors.As(err, &verr) {
+		t.Errorf("语法错误应直接返回: %v", err)
+	}
+}
+
+// TestQueueWaterDefaults 测试低水位默认值不高于配置的高水位，-1 表示队列清空后才恢复
+func TestQueueWaterDefaults(t *testing.T) {
+	tests := []struct {
+		name      string
+		queue     string
+		high, low int
+		percent   int
+		wantErr   string
+	}{
+		{"都使用默认值", `{}`, 90, 50, 50, ""},
+		{"只配置较低的高水位", `{"high_water": 40}`, 40, 20, 20, ""},
+		{"只配置较高的高水位", `{"high_water": 70}`, 70, 50, 50, ""},
+		{"高水位为1", `{"high_water": 1}`, 1, -1, 0, ""},
+		{"低水位-1", `{"high_water": 80, "low_water": -1}`, 80, -1, 0, ""},
+		{"低水位不低于高水位", `{"high_water": 40, "low_water": 40}`, 40, 40, 40, "queue.low_water"},
+		{"低水位小于-1", `{"low_water": -2}`, 90, -2, -2, "queue.low_water"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := decodeConfig([]byte(`{"prover_workers": 1, "node_ids": ["1001"], "queue": ` + tt.queue + `}`))
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("期望通过, 错误: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("错误 = %v, 期望包含 %s", err, tt.wantErr)
+			}
+			if cfg.Queue.HighWater != tt.high || cfg.Queue.LowWater != tt.low || cfg.Queue.LowWaterPercent() != tt.percent {
+				t.Errorf("high_water=%d low_water=%d (生效 %d), 期望 %d %d (%d)",
+					cfg.Queue.HighWater, cfg.Queue.LowWater, cfg.Queue.LowWaterPercent(), tt.high, tt.low, tt.percent)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package deadletter
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"nexus-prover/pkg/types"
+)
+
+// 死信文件后缀
+const entryFileExt = ".json"
+
+// ErrNotFound 死信目录中没有该任务
+var ErrNotFound = errors.New("死信不存在")
+
+// Entry 放弃重试的证明，每个任务一个文件: <dir>/<task_id>.json
+type Entry struct {
+	TaskID      string    `json:"task_id"`
+	ProgramID   string    `json:"program_id"`
+	NodeID      string    `json:"node_id"`
+	Existing    bool      `json:"existing,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`          // 任务创建时间
+	FailedAt    time.Time `json:"failed_at,omitempty"` // 首次提交失败时间
+	DeadAt      time.Time `json:"dead_at"`             // 放弃重试的时间
+	RetryCount  int       `json:"retry_count"`
+	ErrorClass  string    `json:"error_class"`
+	LastError   string    `json:"last_error"`
+	Reason      string    `json:"reason"`              // 放弃原因: 重试次数用完 / 重试队列已满
+	Resubmits   int       `json:"resubmits,omitempty"` // 手动重新提交失败的次数
+	Proof       []byte    `json:"proof"`
+	ProofSize   int       `json:"-"`
+	ProofSHA256 string    `json:"proof_sha256"`
+}
+
+// NewEntry 根据放弃重试的证明创建死信
+func NewEntry(rp *types.RetryProof, reason error) *Entry {
+	return &Entry{
+		TaskID:      rp.Task.TaskID,
+		ProgramID:   rp.Task.ProgramID,
+		NodeID:      rp.Task.NodeID,
+		Existing:    rp.Task.Existing,
+		CreatedAt:   rp.Task.CreatedAt.UTC(),
+		FailedAt:    rp.FailedAt.UTC(),
+		DeadAt:      time.Now().UTC(),
+		RetryCount:  rp.RetryCount,
+		ErrorClass:  rp.Class,
+		LastError:   rp.LastError,
+		Reason:      reason.Error(),
+		Proof:       rp.Proof,
+		ProofSize:   len(rp.Proof),
+		ProofSHA256: proofHash(rp.Proof),
+	}
+}
+
+// proofHash 证明的 SHA-256（与提交时的 proof_hash 相同）
+func proofHash(proof []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(proof))
+}
+
+// Task 重新提交使用的任务
+func (e *Entry) Task() *types.Task {
+	return &types.Task{
+		TaskID:    e.TaskID,
+		ProgramID: e.ProgramID,
+		NodeID:    e.NodeID,
+		CreatedAt: e.CreatedAt,
+		Existing:  e.Existing,
+	}
+}
+
+// Store 死信目录，运行中的 prover 写入，dlq 子命令查看、重新提交和清理；文件先写临时文件再重命名，读取时不会看到写了一半的文件
+type Store struct {
+	dir string
+}
+
+// Open 打开死信目录，不存在时创建
+func Open(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, errors.New("死信目录不能为空")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建死信目录失败: %v", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir 死信目录
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// path 任务的死信文件路径
+func (s *Store) path(taskID string) (string, error) {
+	if taskID == "" || strings.ContainsAny(taskID, `/\`) || taskID == "." || taskID == ".." {
+		return "", fmt.Errorf("无效的任务ID: %q", taskID)
+	}
+	return filepath.Join(s.dir, taskID+entryFileExt), nil
+}
+
+// Put 保存死信，同一任务已存在时覆盖
+func (s *Store) Put(e *Entry) error {
+	path, err := s.path(e.TaskID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	// 重命名前刷盘，避免断电后留下已重命名但内容为空的死信文件
+	tmp := path + ".tmp"
+	if err := writeSync(tmp, append(data, '\n')); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入死信文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入死信文件失败: %v", err)
+	}
+	return nil
+}
+
+// writeSync 写入文件并同步到磁盘
+func writeSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Get 读取任务的死信（包含证明）
+func (s *Store) Get(taskID string) (*Entry, error) {
+	path, err := s.path(taskID)
+	if err != nil {
+		return nil, err
+	}
+	return readEntry(path)
+}
+
+// List 列出全部死信，按放弃时间排序；不保留证明数据，只有 ProofSize
+func (s *Store) List() ([]*Entry, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, entryFileExt) {
+			continue
+		}
+		e, err := readEntry(filepath.Join(s.dir, name))
+		if errors.Is(err, ErrNotFound) {
+			continue // 刚被其他进程删除
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		e.Proof = nil
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].DeadAt.Before(entries[j].DeadAt) })
+	return entries, nil
+}
+
+// Remove 删除任务的死信
+func (s *Store) Remove(taskID string) error {
+	path, err := s.path(taskID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); os.IsNotExist(err) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// readEntry 读取死信文件
+func readEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("解析死信文件失败: %v", err)
+	}
+	e.ProofSize = len(e.Proof)
+	return &e, nil
+}
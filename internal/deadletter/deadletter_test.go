@@ -0,0 +1,122 @@
+package deadletter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nexus-prover/pkg/types"
+)
+
+func testEntry(taskID string, deadAt time.Time) *Entry {
+	e := NewEntry(&types.RetryProof{
+		Task:       &types.Task{TaskID: taskID, ProgramID: "fib_input", NodeID: "1001", CreatedAt: deadAt.Add(-time.Hour)},
+		Proof:      []byte("proof-" + taskID),
+		RetryCount: 3,
+		Class:      "server",
+		LastError:  "HTTP 502",
+		FailedAt:   deadAt.Add(-time.Minute),
+	}, types.ErrRetryExhausted)
+	e.DeadAt = deadAt.UTC()
+	return e
+}
+
+// TestStore 测试死信的保存、读取、列出和删除
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := s.Put(testEntry("t2", now)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(testEntry("t1", now.Add(-time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := s.Get("t2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(e.Proof) != "proof-t2" || e.ProofSize != len("proof-t2") || e.ProofSHA256 != proofHash([]byte("proof-t2")) {
+		t.Errorf("证明 = %q, 大小 %d, 哈希 %s", e.Proof, e.ProofSize, e.ProofSHA256)
+	}
+	if e.RetryCount != 3 || e.ErrorClass != "server" || e.LastError != "HTTP 502" || e.Reason != types.ErrRetryExhausted.Error() {
+		t.Errorf("死信 = %+v", e)
+	}
+	if task := e.Task(); task.TaskID != "t2" || task.NodeID != "1001" || task.ProgramID != "fib_input" {
+		t.Errorf("Task = %+v", task)
+	}
+
+	// 覆盖已有的死信
+	e.Resubmits = 1
+	if err := s.Put(e); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get("t2"); got.Resubmits != 1 {
+		t.Errorf("覆盖后 Resubmits = %d", got.Resubmits)
+	}
+
+	// 写了一半的临时文件和其他文件不计入
+	os.WriteFile(filepath.Join(dir, "t3.json.tmp"), []byte("{"), 0600)
+	os.WriteFile(filepath.Join(dir, "README"), []byte("x"), 0600)
+	entries, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].TaskID != "t1" || entries[1].TaskID != "t2" {
+		t.Fatalf("List = %v, 期望按放弃时间排序的 [t1 t2]", entries)
+	}
+	if entries[0].Proof != nil || entries[0].ProofSize != len("proof-t1") {
+		t.Errorf("List 不应保留证明数据: %q, 大小 %d", entries[0].Proof, entries[0].ProofSize)
+	}
+
+	if err := s.Remove("t1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove("t1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("重复删除: 错误 = %v, 期望 ErrNotFound", err)
+	}
+	if _, err := s.Get("t1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("读取已删除的死信: 错误 = %v, 期望 ErrNotFound", err)
+	}
+}
+
+// TestStoreInvalidTaskID 测试任务ID不能用于访问死信目录以外的文件
+func TestStoreInvalidTaskID(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "dlq")
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 死信目录外的文件
+	outside := filepath.Join(root, "victim.json")
+	if err := os.WriteFile(outside, []byte(`{"task_id": "victim"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, taskID := range []string{"", ".", "..", "../victim", "a/b", `..\victim`, "/etc/passwd"} {
+		t.Run(taskID, func(t *testing.T) {
+			if err := s.Put(testEntry(taskID, time.Now())); err == nil {
+				t.Error("Put 应拒绝")
+			}
+			if _, err := s.Get(taskID); err == nil || errors.Is(err, ErrNotFound) {
+				t.Errorf("Get 错误 = %v, 期望无效的任务ID", err)
+			}
+			if err := s.Remove(taskID); err == nil || errors.Is(err, ErrNotFound) {
+				t.Errorf("Remove 错误 = %v, 期望无效的任务ID", err)
+			}
+		})
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("死信目录外的文件被删除: %v", err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("死信目录中不应有文件: %v", files)
+	}
+}
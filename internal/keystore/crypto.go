@@ -0,0 +1,98 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	kdfPBKDF2SHA256  = "pbkdf2-sha256"
+	cipherAES256GCM  = "aes-256-gcm"
+	pbkdf2Iterations = 600000 // OWASP 推荐的 PBKDF2-HMAC-SHA256 迭代次数
+	saltSize         = 16
+	derivedKeySize   = 32
+)
+
+// cryptoBox 加密后的私钥 seed
+type cryptoBox struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"` // hex
+	Cipher     string `json:"cipher"`
+	Nonce      string `json:"nonce"`      // hex
+	Ciphertext string `json:"ciphertext"` // hex，包含 GCM 认证标签
+}
+
+// seal 使用口令加密 seed
+func seal(passphrase, seed []byte) (*cryptoBox, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(passphrase, salt, pbkdf2Iterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &cryptoBox{
+		KDF:        kdfPBKDF2SHA256,
+		Iterations: pbkdf2Iterations,
+		Salt:       hex.EncodeToString(salt),
+		Cipher:     cipherAES256GCM,
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(gcm.Seal(nil, nonce, seed, nil)),
+	}, nil
+}
+
+// open 使用口令解密 seed
+func (b *cryptoBox) open(passphrase []byte) ([]byte, error) {
+	if b.KDF != kdfPBKDF2SHA256 || b.Cipher != cipherAES256GCM {
+		return nil, fmt.Errorf("不支持的加密方式: %s/%s", b.KDF, b.Cipher)
+	}
+	if b.Iterations <= 0 {
+		return nil, fmt.Errorf("无效的迭代次数: %d", b.Iterations)
+	}
+	salt, err := hex.DecodeString(b.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("salt 格式错误: %v", err)
+	}
+	nonce, err := hex.DecodeString(b.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("nonce 格式错误: %v", err)
+	}
+	ciphertext, err := hex.DecodeString(b.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("ciphertext 格式错误: %v", err)
+	}
+	gcm, err := newGCM(passphrase, salt, b.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("nonce 长度错误: %d", len(nonce))
+	}
+	seed, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return seed, nil
+}
+
+// newGCM 由口令派生 AES-256 密钥
+func newGCM(passphrase, salt []byte, iterations int) (cipher.AEAD, error) {
+	key := pbkdf2.Key(passphrase, salt, iterations, derivedKeySize, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,79 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"testing"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// TestPBKDF2Vectors RFC 6070 (HMAC-SHA1) 与 RFC 7914 第 11 节 (HMAC-SHA256) 测试向量
+func TestPBKDF2Vectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		h        func() hash.Hash
+		password string
+		salt     string
+		iter     int
+		keyLen   int
+		want     string
+	}{
+		{"sha1迭代1次", sha1.New, "password", "salt", 1, 20, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{"sha1迭代2次", sha1.New, "password", "salt", 2, 20, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{"sha1迭代4096次", sha1.New, "password", "salt", 4096, 20, "4b007901b765489abead49d926f721d065a429c1"},
+		{"sha1多个块", sha1.New, "passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, 25, "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+		{"sha1含NUL字节", sha1.New, "pass\x00word", "sa\x00lt", 4096, 16, "56fa6aa75548099dcc37d7f03425e0c3"},
+		{"sha256迭代1次", sha256.New, "passwd", "salt", 1, 64, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"sha256迭代80000次", sha256.New, "Password", "NaCl", 80000, 64, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hex.EncodeToString(pbkdf2.Key([]byte(tt.password), []byte(tt.salt), tt.iter, tt.keyLen, tt.h))
+			if got != tt.want {
+				t.Errorf("派生密钥 = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSealOpen 测试加密后用相同口令解密，口令错误或密文被篡改时返回 ErrWrongPassphrase
+func TestSealOpen(t *testing.T) {
+	seed := bytes.Repeat([]byte{7}, 32)
+	box, err := seal([]byte("correct horse"), seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if box.KDF != kdfPBKDF2SHA256 || box.Cipher != cipherAES256GCM || box.Iterations != pbkdf2Iterations {
+		t.Errorf("加密参数 = %s/%s/%d", box.KDF, box.Cipher, box.Iterations)
+	}
+	got, err := box.open([]byte("correct horse"))
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if !bytes.Equal(got, seed) {
+		t.Errorf("解密结果 = %x, 期望 %x", got, seed)
+	}
+
+	if _, err := box.open([]byte("wrong horse")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("口令错误: 错误 = %v, 期望 ErrWrongPassphrase", err)
+	}
+
+	tampered := *box
+	ciphertext, _ := hex.DecodeString(box.Ciphertext)
+	ciphertext[0] ^= 1
+	tampered.Ciphertext = hex.EncodeToString(ciphertext)
+	if _, err := tampered.open([]byte("correct horse")); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("密文被篡改: 错误 = %v, 期望 ErrWrongPassphrase", err)
+	}
+
+	unsupported := *box
+	unsupported.KDF = "scrypt"
+	if _, err := unsupported.open([]byte("correct horse")); err == nil || errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("不支持的加密方式: 错误 = %v", err)
+	}
+}
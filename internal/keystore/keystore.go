@@ -0,0 +1,380 @@
+package keystore
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 密钥文件后缀
+const keyFileExt = ".json"
+
+// ErrKeyNotFound 节点没有密钥文件
+var ErrKeyNotFound = errors.New("密钥不存在")
+
+// ErrWrongPassphrase 口令错误或密钥文件被篡改
+var ErrWrongPassphrase = errors.New("口令错误或密钥文件已损坏")
+
+// keyFile 密钥文件格式，每个节点一个文件: <key_dir>/<node_id>.json
+type keyFile struct {
+	NodeID     string     `json:"node_id"`
+	PublicKey  string     `json:"public_key"` // hex
+	CreatedAt  time.Time  `json:"created_at"`
+	PrivateKey string     `json:"private_key,omitempty"` // 未加密时保存 seed（hex）
+	Crypto     *cryptoBox `json:"crypto,omitempty"`      // 加密时保存加密后的 seed
+}
+
+// KeyInfo 密钥信息（不含私钥）
+type KeyInfo struct {
+	NodeID    string
+	PublicKey ed25519.PublicKey
+	CreatedAt time.Time
+	Encrypted bool
+	Path      string
+}
+
+// Store 按节点ID保存 Ed25519 密钥对，首次使用时自动生成并持久化
+type Store struct {
+	dir        string
+	passphrase []byte // 为空表示不加密
+
+	mu   sync.Mutex
+	keys map[string]ed25519.PrivateKey // 已加载的私钥缓存
+
+	filesMu sync.RWMutex
+	files   map[string]string // 节点级 key_file 覆盖: 节点ID => 密钥文件
+}
+
+// Open 打开密钥目录，不存在时创建；passphrase 为空时新密钥以明文保存
+func Open(dir, passphrase string) (*Store, error) {
+	if dir == "" {
+		return nil, errors.New("密钥目录不能为空")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建密钥目录失败: %v", err)
+	}
+	return &Store{
+		dir:        dir,
+		passphrase: []byte(passphrase),
+		keys:       make(map[string]ed25519.PrivateKey),
+	}, nil
+}
+
+// Dir 密钥目录
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Encrypted 新密钥是否加密保存
+func (s *Store) Encrypted() bool {
+	return len(s.passphrase) > 0
+}
+
+// SetKeyFiles 设置节点级密钥文件（配置中节点的 key_file），未设置的节点使用 <key_dir>/<node_id>.json；
+// 密钥文件变化的节点清除已加载的私钥，下次使用时从新文件读取
+func (s *Store) SetKeyFiles(files map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filesMu.Lock()
+	defer s.filesMu.Unlock()
+	for nodeID := range s.keys {
+		if s.files[nodeID] != files[nodeID] {
+			delete(s.keys, nodeID)
+		}
+	}
+	s.files = make(map[string]string, len(files))
+	for nodeID, path := range files {
+		s.files[nodeID] = path
+	}
+}
+
+// path 节点密钥文件路径
+func (s *Store) path(nodeID string) (string, error) {
+	if nodeID == "" || strings.ContainsAny(nodeID, `/\`) || nodeID == "." || nodeID == ".." {
+		return "", fmt.Errorf("无效的节点ID: %q", nodeID)
+	}
+	s.filesMu.RLock()
+	file, ok := s.files[nodeID]
+	s.filesMu.RUnlock()
+	if ok {
+		return file, nil
+	}
+	return filepath.Join(s.dir, nodeID+keyFileExt), nil
+}
+
+// Get 获取节点私钥，不存在时生成新密钥并保存
+func (s *Store) Get(nodeID string) (ed25519.PrivateKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if priv, ok := s.keys[nodeID]; ok {
+		return priv, nil
+	}
+	priv, err := s.load(nodeID)
+	if errors.Is(err, ErrKeyNotFound) {
+		priv, err = s.create(nodeID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.keys[nodeID] = priv
+	return priv, nil
+}
+
+// PublicKey 获取节点公钥，不存在时生成新密钥
+func (s *Store) PublicKey(nodeID string) (ed25519.PublicKey, error) {
+	priv, err := s.Get(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return priv.Public().(ed25519.PublicKey), nil
+}
+
+// Load 只读取已有的节点私钥，不存在时返回 ErrKeyNotFound
+func (s *Store) Load(nodeID string) (ed25519.PrivateKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if priv, ok := s.keys[nodeID]; ok {
+		return priv, nil
+	}
+	priv, err := s.load(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	s.keys[nodeID] = priv
+	return priv, nil
+}
+
+// Info 读取节点密钥信息，不需要口令
+func (s *Store) Info(nodeID string) (*KeyInfo, error) {
+	path, err := s.path(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	kf, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return kf.info(path)
+}
+
+// List 列出目录中的全部密钥，按节点ID排序
+func (s *Store) List() ([]*KeyInfo, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var infos []*KeyInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, keyFileExt) {
+			continue
+		}
+		info, err := s.Info(strings.TrimSuffix(name, keyFileExt))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].NodeID < infos[j].NodeID })
+	return infos, nil
+}
+
+// Rotate 为节点生成新密钥，旧密钥文件重命名为 <node_id>.json.<时间戳>.bak 保留。
+// 新密钥先写入临时文件，生成或写入失败时旧密钥保持不变
+func (s *Store) Rotate(nodeID string) (ed25519.PrivateKey, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path, err := s.path(nodeID)
+	if err != nil {
+		return nil, "", err
+	}
+	priv, data, err := s.generate(nodeID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, "", fmt.Errorf("创建密钥目录失败: %v", err)
+	}
+	tmp, err := writeTempKeyFile(path, data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	backup := ""
+	if _, err := os.Stat(path); err == nil {
+		backup = fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102150405"))
+		if err := os.Rename(path, backup); err != nil {
+			os.Remove(tmp)
+			return nil, "", fmt.Errorf("备份旧密钥失败: %v", err)
+		}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		if backup != "" {
+			// 恢复旧密钥
+			os.Rename(backup, path)
+		}
+		return nil, "", fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	s.keys[nodeID] = priv
+	return priv, backup, nil
+}
+
+// writeTempKeyFile 将密钥写入与 path 同目录的临时文件并同步到磁盘，返回临时文件路径
+func writeTempKeyFile(path string, data []byte) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return "", fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	return tmp, nil
+}
+
+// load 读取并解密节点私钥（调用方持锁）
+func (s *Store) load(nodeID string) (ed25519.PrivateKey, error) {
+	path, err := s.path(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	kf, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var seed []byte
+	if kf.Crypto != nil {
+		if len(s.passphrase) == 0 {
+			return nil, fmt.Errorf("节点 %s 的密钥已加密，需要提供口令", nodeID)
+		}
+		seed, err = kf.Crypto.open(s.passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("节点 %s: %w", nodeID, err)
+		}
+	} else {
+		seed, err = hex.DecodeString(kf.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("节点 %s 私钥格式错误: %v", nodeID, err)
+		}
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("节点 %s 私钥长度错误: %d", nodeID, len(seed))
+	}
+
+	priv := ed25519.NewKeyFromSeed(seed)
+	if hex.EncodeToString(priv.Public().(ed25519.PublicKey)) != kf.PublicKey {
+		return nil, fmt.Errorf("节点 %s 的公钥与私钥不匹配", nodeID)
+	}
+	return priv, nil
+}
+
+// create 生成新密钥并写入文件（调用方持锁）
+func (s *Store) create(nodeID string) (ed25519.PrivateKey, error) {
+	path, err := s.path(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	priv, data, err := s.generate(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("创建密钥目录失败: %v", err)
+	}
+	// O_EXCL 避免覆盖其他进程刚生成的密钥
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	return priv, nil
+}
+
+// generate 生成新密钥并编码为密钥文件内容，口令不为空时加密 seed
+func (s *Store) generate(nodeID string) (ed25519.PrivateKey, []byte, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kf := &keyFile{
+		NodeID:    nodeID,
+		PublicKey: hex.EncodeToString(pub),
+		CreatedAt: time.Now().UTC(),
+	}
+	if s.Encrypted() {
+		kf.Crypto, err = seal(s.passphrase, priv.Seed())
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		kf.PrivateKey = hex.EncodeToString(priv.Seed())
+	}
+
+	data, err := json.MarshalIndent(kf, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv, append(data, '\n'), nil
+}
+
+// readKeyFile 读取密钥文件
+func readKeyFile(path string) (*keyFile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err != nil {
+		return nil, fmt.Errorf("解析密钥文件失败: %v", err)
+	}
+	return &kf, nil
+}
+
+// info 转换为 KeyInfo
+func (kf *keyFile) info(path string) (*KeyInfo, error) {
+	pub, err := hex.DecodeString(kf.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("公钥格式错误: %q", kf.PublicKey)
+	}
+	return &KeyInfo{
+		NodeID:    kf.NodeID,
+		PublicKey: ed25519.PublicKey(pub),
+		CreatedAt: kf.CreatedAt,
+		Encrypted: kf.Crypto != nil,
+		Path:      path,
+	}, nil
+}
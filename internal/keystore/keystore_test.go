@@ -0,0 +1,100 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStoreEncrypted 测试加密保存的密钥只能用相同口令读取
+func TestStoreEncrypted(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := store.Get("1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "1001.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "private_key") {
+		t.Error("加密保存时密钥文件不应包含明文私钥")
+	}
+
+	reopened, _ := Open(dir, "secret")
+	loaded, err := reopened.Load("1001")
+	if err != nil {
+		t.Fatalf("重新打开后读取失败: %v", err)
+	}
+	if !bytes.Equal(loaded, priv) {
+		t.Error("重新读取的私钥与生成的不一致")
+	}
+
+	wrong, _ := Open(dir, "guess")
+	if _, err := wrong.Load("1001"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("口令错误: 错误 = %v, 期望 ErrWrongPassphrase", err)
+	}
+}
+
+// TestRotate 测试轮换后旧密钥保留为备份，新密钥立即生效
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priv, backup, err := store.Rotate("1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backup != "" {
+		t.Errorf("没有旧密钥时备份 = %q, 期望为空", backup)
+	}
+
+	rotated, backup, err := store.Rotate("1001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(rotated, priv) {
+		t.Error("轮换后应生成新密钥")
+	}
+	old, err := readKeyFile(backup)
+	if err != nil {
+		t.Fatalf("读取备份失败: %v", err)
+	}
+	info, err := old.info(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(info.PublicKey, priv.Public().(ed25519.PublicKey)) {
+		t.Error("备份应为轮换前的密钥")
+	}
+	if got, _ := store.Get("1001"); !bytes.Equal(got, rotated) {
+		t.Error("缓存中应为新密钥")
+	}
+	reopened, _ := Open(dir, "")
+	if got, err := reopened.Load("1001"); err != nil || !bytes.Equal(got, rotated) {
+		t.Errorf("重新读取应为新密钥: %v", err)
+	}
+
+	// 临时文件不残留，List 只返回当前密钥
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("残留临时文件 %s", e.Name())
+		}
+	}
+	infos, err := store.List()
+	if err != nil || len(infos) != 1 || infos[0].NodeID != "1001" {
+		t.Errorf("List = %v, %v", infos, err)
+	}
+}
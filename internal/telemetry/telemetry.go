@@ -0,0 +1,257 @@
+package telemetry
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DEFAULT_LOCATION 未配置地理位置时上报的值
+const DEFAULT_LOCATION = "unknown"
+
+// MEMORY_SAMPLE_INTERVAL 普通模式下证明计算期间采样进程物理内存的间隔
+const MEMORY_SAMPLE_INTERVAL = 20 * time.Millisecond
+
+// Sample 一次提交上报的遥测数据
+type Sample struct {
+	FlopsPerSec    int64  // 启动基准测试测得的每秒浮点运算次数
+	MemoryUsed     int64  // 证明计算的峰值内存（字节）
+	MemoryCapacity int64  // 节点可用内存上限（字节）
+	Location       string // 地理位置
+}
+
+// Collector 节点遥测数据收集器
+type Collector struct {
+	location       string
+	flopsPerSec    int64
+	memoryCapacity int64
+
+	mu         sync.Mutex
+	taskMemory map[string]int64  // 任务ID -> 证明峰值内存，任务结束（Forget）时删除
+	locations  map[string]string // 节点ID -> 节点级地理位置
+}
+
+// New 创建遥测收集器，读取内存上限
+func New(location string) *Collector {
+	if location == "" {
+		location = DEFAULT_LOCATION
+	}
+	return &Collector{
+		location:       location,
+		memoryCapacity: MemoryCapacity(),
+		taskMemory:     make(map[string]int64),
+	}
+}
+
+// Location 默认地理位置
+func (c *Collector) Location() string {
+	return c.location
+}
+
+// SetNodeLocations 设置节点级地理位置（配置中节点的 telemetry_location）
+func (c *Collector) SetNodeLocations(locations map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.locations = make(map[string]string, len(locations))
+	for nodeID, location := range locations {
+		c.locations[nodeID] = location
+	}
+}
+
+// NodeLocation 节点的地理位置，没有节点级配置时为空（使用默认地理位置）
+func (c *Collector) NodeLocation(nodeID string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.locations[nodeID]
+}
+
+// RunBenchmark 运行FLOPS基准测试并保存结果
+func (c *Collector) RunBenchmark(d time.Duration) int64 {
+	flops := int64(MeasureFLOPS(d))
+	atomic.StoreInt64(&c.flopsPerSec, flops)
+	return flops
+}
+
+// RecordMemoryUsed 记录任务证明计算的峰值内存（字节）
+func (c *Collector) RecordMemoryUsed(taskID string, bytes int64) {
+	if bytes <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.taskMemory[taskID] = bytes
+}
+
+// Forget 任务结束，清除任务的内存记录
+func (c *Collector) Forget(taskID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.taskMemory, taskID)
+}
+
+// Sample 任务的遥测数据，重试提交时内存记录仍然保留；没有记录（如重启后恢复的证明）时内存为0，
+// 不借用其他任务的值；location 为空时使用默认地理位置
+func (c *Collector) Sample(taskID, location string) Sample {
+	c.mu.Lock()
+	mem := c.taskMemory[taskID]
+	c.mu.Unlock()
+
+	if location == "" {
+		location = c.location
+	}
+	return Sample{
+		FlopsPerSec:    atomic.LoadInt64(&c.flopsPerSec),
+		MemoryUsed:     mem,
+		MemoryCapacity: c.memoryCapacity,
+		Location:       location,
+	}
+}
+
+// TrackPeakMemory 开始定期采样进程物理内存，返回的函数停止采样并返回期间的峰值（字节）；
+// 普通模式下多个证明共用一个进程，峰值包含同时进行的其他证明
+func TrackPeakMemory(interval time.Duration) func() int64 {
+	peak := procRSS()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if rss := procRSS(); rss > peak {
+					peak = rss
+				}
+			}
+		}
+	}()
+	return func() int64 {
+		close(stop)
+		<-done
+		if rss := procRSS(); rss > peak {
+			peak = rss
+		}
+		return peak
+	}
+}
+
+// procRSS 读取 /proc/self/status 中的 VmRSS（字节）
+func procRSS() int64 {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// VmRSS:    123456 kB
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+// MeasureFLOPS 单线程浮点基准测试，返回每秒浮点运算次数
+func MeasureFLOPS(d time.Duration) float64 {
+	const inner = 1 << 16
+	// 4条独立的乘加链，收敛到固定值，避免溢出
+	x0, x1, x2, x3 := 0.1, 0.2, 0.3, 0.4
+	a, b := 0.5, 0.25
+	var ops int64
+	start := time.Now()
+	for time.Since(start) < d {
+		for i := 0; i < inner; i++ {
+			x0 = x0*a + b
+			x1 = x1*a + b
+			x2 = x2*a + b
+			x3 = x3*a + b
+		}
+		ops += inner * 8 // 每次迭代 4 乘 + 4 加
+	}
+	elapsed := time.Since(start).Seconds()
+	benchmarkSink = x0 + x1 + x2 + x3
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(ops) / elapsed
+}
+
+// benchmarkSink 保存基准测试结果，防止计算被编译器优化掉
+var benchmarkSink float64
+
+// MemoryCapacity 节点可用内存上限（字节）：取物理内存与cgroup限制中的较小值
+func MemoryCapacity() int64 {
+	capacity := memTotal()
+	for _, path := range []string{
+		"/sys/fs/cgroup/memory.max",                   // cgroup v2
+		"/sys/fs/cgroup/memory/memory.limit_in_bytes", // cgroup v1
+	} {
+		if limit := readCgroupLimit(path); limit > 0 && (capacity == 0 || limit < capacity) {
+			capacity = limit
+		}
+	}
+	return capacity
+}
+
+// memTotal 读取 /proc/meminfo 中的 MemTotal（字节）
+func memTotal() int64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// MemTotal:       16318480 kB
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb * 1024
+		}
+	}
+	return 0
+}
+
+// readCgroupLimit 读取cgroup内存限制，"max"或超大值视为无限制
+func readCgroupLimit(path string) int64 {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" || value == "max" {
+		return 0
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 || limit >= 1<<62 {
+		return 0
+	}
+	return limit
+}
+
+// FormatBytes 格式化字节数用于日志
+func FormatBytes(b int64) string {
+	const mb = 1024 * 1024
+	if b >= 1024*mb {
+		return fmt.Sprintf("%.2fGB", float64(b)/float64(1024*mb))
+	}
+	return fmt.Sprintf("%.2fMB", float64(b)/float64(mb))
+}
@@ -0,0 +1,42 @@
+package telemetry
+
+import "testing"
+
+// TestSampleMemory 测试内存记录在重试提交时保留，任务结束后清除，没有记录时不借用其他任务的值
+func TestSampleMemory(t *testing.T) {
+	c := New("")
+	c.RecordMemoryUsed("t1", 100)
+	c.RecordMemoryUsed("t2", 200)
+	c.RecordMemoryUsed("t3", 0) // 没有采样到时不记录
+
+	tests := []struct {
+		name   string
+		taskID string
+		forget string
+		want   int64
+	}{
+		{"首次提交", "t1", "", 100},
+		{"重试提交仍保留", "t1", "", 100},
+		{"没有记录的任务", "t3", "", 0},
+		{"重启后恢复的任务", "recovered", "", 0},
+		{"任务结束后清除", "t1", "t1", 0},
+		{"其他任务不受影响", "t2", "t1", 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.forget != "" {
+				c.Forget(tt.forget)
+			}
+			sample := c.Sample(tt.taskID, "")
+			if sample.MemoryUsed != tt.want {
+				t.Errorf("MemoryUsed = %d, 期望 %d", sample.MemoryUsed, tt.want)
+			}
+			if sample.Location != DEFAULT_LOCATION {
+				t.Errorf("Location = %q", sample.Location)
+			}
+		})
+	}
+	if got := c.Sample("t2", "Tokyo").Location; got != "Tokyo" {
+		t.Errorf("节点级地理位置 = %q", got)
+	}
+}
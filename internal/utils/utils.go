@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"io/ioutil"
 	"strings"
@@ -25,17 +26,6 @@ func SleepWithContext(ctx context.Context, duration time.Duration) bool {
 	}
 }
 
-// IsRateLimitError 检查是否是速率限制错误
-func IsRateLimitError(err error) bool {
-	if err == nil {
-		return false
-	}
-	errStr := err.Error()
-	return strings.Contains(errStr, "rate limit exceeded") ||
-		strings.Contains(errStr, "Rate limit exceeded") ||
-		strings.Contains(errStr, "429")
-}
-
 // ClearProofData 清理证明数据，帮助GC回收内存
 func ClearProofData(proof []byte) {
 	if proof != nil {
@@ -65,3 +55,14 @@ func GetProcMemUsage() float64 {
 	}
 	return 0
 }
+
+// NewUUID 生成随机UUIDv4
+func NewUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // 版本4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"nexus-prover/internal/utils"
+	"nexus-prover/pkg/types"
+)
+
+// backpressure 根据任务队列深度控制任务获取，不向服务端要队列放不下的任务：
+// 深度达到高水位时暂停获取，回落到低水位后恢复；未暂停时每批只取空闲worker能立即处理的数量
+// 加上补足到低水位的余量，且不超过队列剩余空间，worker都在忙且队列已有低水位的缓冲时跳过本轮
+type backpressure struct {
+	queue     queueProbe
+	highWater int // 百分比
+	lowWater  int // 百分比
+	paused    bool
+}
+
+// queueProbe 背压读取的队列状态，由 *types.TaskQueue 实现
+type queueProbe interface {
+	Len() int
+	Capacity() int
+	Idle() int
+}
+
+var _ queueProbe = (*types.TaskQueue)(nil)
+
+func newBackpressure(queue queueProbe, highWater, lowWater int) *backpressure {
+	return &backpressure{queue: queue, highWater: highWater, lowWater: lowWater}
+}
+
+// limit 本次最多获取的任务数（不超过 batchSize），返回 0 表示暂停获取或跳过本轮
+func (b *backpressure) limit(batchSize int) int {
+	depth, capacity, idle := b.queue.Len(), b.queue.Capacity(), b.queue.Idle()
+	high := (capacity*b.highWater + 99) / 100 // 向上取整，容量很小时至少为1
+	low := capacity * b.lowWater / 100
+	switch {
+	case !b.paused && depth >= high:
+		b.paused = true
+		utils.LogWithTime("[fetcher] 🚦 队列深度 %d/%d 达到高水位(%d%%)，暂停获取任务，空闲worker: %d", depth, capacity, b.highWater, idle)
+	case b.paused && depth <= low:
+		b.paused = false
+		utils.LogWithTime("[fetcher] 🟢 队列深度 %d/%d 回落到低水位(%d%%)，恢复获取任务，空闲worker: %d", depth, capacity, b.lowWater, idle)
+	}
+	if b.paused {
+		return 0
+	}
+	n := idle
+	if depth < low {
+		n += low - depth
+	}
+	if free := capacity - depth; free < n {
+		n = free
+	}
+	if n > batchSize {
+		n = batchSize
+	}
+	return n
+}
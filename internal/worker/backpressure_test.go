@@ -0,0 +1,98 @@
+package worker
+
+import "testing"
+
+// fakeQueue 可直接设置深度和空闲worker数的队列状态
+type fakeQueue struct {
+	depth, capacity, idle int
+}
+
+func (q *fakeQueue) Len() int      { return q.depth }
+func (q *fakeQueue) Capacity() int { return q.capacity }
+func (q *fakeQueue) Idle() int     { return q.idle }
+
+// TestBackpressureLimit 测试高低水位的暂停/恢复滞回、按空闲worker加低水位余量取任务、不超过剩余空间和 batch_size
+func TestBackpressureLimit(t *testing.T) {
+	type step struct {
+		depth, idle, batch int
+		want               int
+		paused             bool
+	}
+	tests := []struct {
+		name      string
+		capacity  int
+		high, low int
+		steps     []step
+	}{
+		{
+			name: "补足到低水位", capacity: 10, high: 90, low: 50,
+			steps: []step{
+				{depth: 0, idle: 0, batch: 10, want: 5},
+				{depth: 0, idle: 0, batch: 3, want: 3},
+				{depth: 2, idle: 0, batch: 10, want: 3},
+			},
+		},
+		{
+			name: "空闲worker加余量", capacity: 10, high: 90, low: 50,
+			steps: []step{
+				{depth: 0, idle: 2, batch: 10, want: 7},
+				{depth: 6, idle: 2, batch: 10, want: 2},
+			},
+		},
+		{
+			name: "worker都在忙且已有缓冲时跳过", capacity: 10, high: 90, low: 50,
+			steps: []step{
+				{depth: 5, idle: 0, batch: 10, want: 0},
+				{depth: 8, idle: 0, batch: 10, want: 0},
+			},
+		},
+		{
+			name: "高水位暂停，回落到低水位才恢复", capacity: 10, high: 90, low: 50,
+			steps: []step{
+				{depth: 9, idle: 3, batch: 10, want: 0, paused: true},
+				{depth: 7, idle: 3, batch: 10, want: 0, paused: true},
+				{depth: 6, idle: 3, batch: 10, want: 0, paused: true},
+				{depth: 5, idle: 3, batch: 10, want: 3},
+				{depth: 8, idle: 3, batch: 10, want: 2},
+			},
+		},
+		{
+			name: "不超过剩余空间", capacity: 10, high: 100, low: 90,
+			steps: []step{
+				{depth: 7, idle: 5, batch: 10, want: 3},
+				{depth: 9, idle: 5, batch: 10, want: 1},
+			},
+		},
+		{
+			name: "低水位为0时只按空闲worker取", capacity: 10, high: 50, low: 0,
+			steps: []step{
+				{depth: 0, idle: 0, batch: 10, want: 0},
+				{depth: 0, idle: 2, batch: 10, want: 2},
+				{depth: 5, idle: 2, batch: 10, want: 0, paused: true},
+				{depth: 1, idle: 2, batch: 10, want: 0, paused: true},
+				{depth: 0, idle: 2, batch: 10, want: 2},
+			},
+		},
+		{
+			name: "容量很小时高水位向上取整", capacity: 1, high: 90, low: 50,
+			steps: []step{
+				{depth: 0, idle: 1, batch: 3, want: 1},
+				{depth: 1, idle: 0, batch: 3, want: 0, paused: true},
+				{depth: 0, idle: 0, batch: 3, want: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &fakeQueue{capacity: tt.capacity}
+			bp := newBackpressure(q, tt.high, tt.low)
+			for i, s := range tt.steps {
+				q.depth, q.idle = s.depth, s.idle
+				if got := bp.limit(s.batch); got != s.want || bp.paused != s.paused {
+					t.Errorf("第 %d 步 深度=%d 空闲=%d: limit = %d, paused = %v, 期望 %d, %v",
+						i+1, s.depth, s.idle, got, bp.paused, s.want, s.paused)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"nexus-prover/internal/api"
+	"nexus-prover/internal/config"
+	"nexus-prover/internal/utils"
+	pb "nexus-prover/proto"
+)
+
+// NodeSet 运行中的节点ID集合，由配置的节点（可热更新）和自动发现的节点按发现模式合并
+type NodeSet struct {
+	mu         sync.RWMutex
+	ids        []string
+	mode       string                       // 节点自动发现模式
+	configured []string                     // 配置文件中的 node_ids
+	overrides  map[string]config.NodeConfig // 配置文件中的节点级覆盖，按节点ID索引
+	discovered []string                     // 最近一次自动发现的CLI节点，nil 表示尚未发现成功
+}
+
+// NewNodeSet 创建节点集合，自动发现成功之前只使用配置的节点
+func NewNodeSet(mode string, configured []config.NodeConfig) *NodeSet {
+	ns := &NodeSet{mode: mode}
+	ns.SetConfigured(configured)
+	return ns
+}
+
+// List 返回节点ID列表的副本
+func (ns *NodeSet) List() []string {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	return append([]string(nil), ns.ids...)
+}
+
+// Len 节点数量
+func (ns *NodeSet) Len() int {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	return len(ns.ids)
+}
+
+// Node 节点的配置（含节点级覆盖），自动发现的节点返回只有ID的配置
+func (ns *NodeSet) Node(id string) config.NodeConfig {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	if n, ok := ns.overrides[id]; ok {
+		return n
+	}
+	return config.NodeConfig{ID: id}
+}
+
+// SetConfigured 更新配置的节点及其节点级覆盖（配置热更新），返回新增和移除的节点
+func (ns *NodeSet) SetConfigured(nodes []config.NodeConfig) (added, removed []string) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	ns.configured = make([]string, 0, len(nodes))
+	ns.overrides = make(map[string]config.NodeConfig, len(nodes))
+	for _, n := range nodes {
+		ns.configured = append(ns.configured, n.ID)
+		ns.overrides[n.ID] = n
+	}
+	return ns.set(ns.resolve(ns.discovered))
+}
+
+// SetDiscovered 更新自动发现的节点；合并结果为空时保留当前节点并返回 ok=false
+func (ns *NodeSet) SetDiscovered(ids []string) (added, removed []string, ok bool) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if ids == nil {
+		ids = []string{}
+	}
+	next := ns.resolve(ids)
+	if len(next) == 0 {
+		return nil, nil, false
+	}
+	ns.discovered = ids
+	added, removed = ns.set(next)
+	return added, removed, true
+}
+
+// resolve 按发现模式合并配置的节点和发现的节点，尚未发现成功时只使用配置的节点
+func (ns *NodeSet) resolve(discovered []string) []string {
+	if discovered == nil {
+		return dedupNodeIDs(ns.configured)
+	}
+	return ResolveNodeIDs(ns.mode, ns.configured, discovered)
+}
+
+// set 替换节点列表（去重并保持顺序），返回新增和移除的节点，调用方持有锁
+func (ns *NodeSet) set(ids []string) (added, removed []string) {
+	ids = dedupNodeIDs(ids)
+
+	old := make(map[string]bool, len(ns.ids))
+	for _, id := range ns.ids {
+		old[id] = true
+	}
+	next := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		next[id] = true
+		if !old[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range ns.ids {
+		if !next[id] {
+			removed = append(removed, id)
+		}
+	}
+	ns.ids = ids
+	return added, removed
+}
+
+// dedupNodeIDs 去掉空值和重复值，保持原有顺序
+func dedupNodeIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out
+}
+
+// DiscoverNodes 查询用户的全部节点，只保留CLI节点
+func DiscoverNodes(ctx context.Context, apiClient *api.Client, cfg *config.Config) ([]string, error) {
+	user := cfg.UserKey()
+	if user == "" {
+		return nil, fmt.Errorf("节点自动发现需要配置 wallet_address 或 user_id")
+	}
+	nodes, err := apiClient.ListUserNodes(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, node := range nodes {
+		if node.NodeType == pb.NodeType_CLI_PROVER {
+			ids = append(ids, node.NodeId)
+		}
+	}
+	return ids, nil
+}
+
+// ResolveNodeIDs 按发现模式合并配置的节点和发现的节点
+func ResolveNodeIDs(mode string, configured, discovered []string) []string {
+	switch mode {
+	case config.NODE_DISCOVERY_REPLACE:
+		return dedupNodeIDs(discovered)
+	case config.NODE_DISCOVERY_MERGE:
+		return dedupNodeIDs(append(append([]string(nil), configured...), discovered...))
+	default:
+		return dedupNodeIDs(configured)
+	}
+}
+
+// NodeDiscovery 节点自动发现worker - 周期刷新用户节点列表并更新节点集合
+func NodeDiscovery(ctx context.Context, apiClient *api.Client, cfg *config.Config, nodes *NodeSet, wg *sync.WaitGroup) {
+	defer wg.Done()
+	interval := time.Duration(cfg.NodeDiscoveryInterval) * time.Second
+	utils.LogWithTime("[discovery] 启动节点自动发现 (模式: %s, 间隔: %s)", cfg.NodeDiscovery, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			utils.LogWithTime("[discovery] Shutting down...")
+			return
+		case <-ticker.C:
+			discovered, err := DiscoverNodes(ctx, apiClient, cfg)
+			if err != nil {
+				utils.LogWithTime("[discovery] ⚠️ 刷新节点列表失败: %v，保留当前节点", err)
+				continue
+			}
+			added, removed, ok := nodes.SetDiscovered(discovered)
+			if !ok {
+				utils.LogWithTime("[discovery] ⚠️ 刷新后节点列表为空，保留当前节点")
+				continue
+			}
+			if len(added) > 0 || len(removed) > 0 {
+				utils.LogWithTime("[discovery] 🔄 节点列表已更新: 新增 %v, 移除 %v, 当前 %d 个节点", added, removed, nodes.Len())
+			}
+		}
+	}
+}
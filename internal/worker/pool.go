@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"nexus-prover/internal/config"
+)
+
+// Settings 可热更新的运行参数，配置重新加载时由 Update 更新
+type Settings struct {
+	requestDelay     int64 // 秒
+	submitWaitSecond int64
+}
+
+// NewSettings 根据配置创建运行参数
+func NewSettings(cfg *config.Config) *Settings {
+	s := &Settings{}
+	s.Update(cfg)
+	return s
+}
+
+// Update 应用新配置中的运行参数
+func (s *Settings) Update(cfg *config.Config) {
+	atomic.StoreInt64(&s.requestDelay, int64(cfg.RequestDelay))
+	atomic.StoreInt64(&s.submitWaitSecond, int64(cfg.ProverSubmitWaitSecond))
+}
+
+// RequestDelay 每轮遍历所有节点后的等待时间
+func (s *Settings) RequestDelay() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.requestDelay)) * time.Second
+}
+
+// SubmitWaitSecond 提交证明前随机等待的上限（秒），未配置时默认10秒
+func (s *Settings) SubmitWaitSecond() int {
+	if v := atomic.LoadInt64(&s.submitWaitSecond); v > 0 {
+		return int(v)
+	}
+	return 10
+}
+
+// WorkerPool 证明计算worker池，支持运行时调整worker数量
+//
+// 缩容时关闭多出的worker的 stop 通道，worker处理完当前任务（包括提交）后退出，不会丢弃证明。
+type WorkerPool struct {
+	wg     *sync.WaitGroup
+	run    func(id int, stop <-chan struct{})
+	mu     sync.Mutex
+	stops  []chan struct{}
+	nextID int
+}
+
+// NewWorkerPool 创建worker池，run 在独立的goroutine中运行一个worker，stop 关闭时应退出
+func NewWorkerPool(wg *sync.WaitGroup, run func(id int, stop <-chan struct{})) *WorkerPool {
+	return &WorkerPool{wg: wg, run: run}
+}
+
+// Resize 调整worker数量，返回启动和停止的worker数
+func (p *WorkerPool) Resize(n int) (started, stopped int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.stops) < n {
+		stop := make(chan struct{})
+		p.stops = append(p.stops, stop)
+		id := p.nextID
+		p.nextID++
+		p.wg.Add(1)
+		go p.run(id, stop)
+		started++
+	}
+	for len(p.stops) > n {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+		stopped++
+	}
+	return started, stopped
+}
+
+// stopContext 返回在 ctx 取消或 stop 关闭时取消的上下文，用于worker阻塞等待任务
+func stopContext(ctx context.Context, stop <-chan struct{}) (context.Context, context.CancelFunc) {
+	waitCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-waitCtx.Done():
+		}
+	}()
+	return waitCtx, cancel
+}
+
+// Size 当前worker数量（不含正在退出的worker）
+func (p *WorkerPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.stops)
+}
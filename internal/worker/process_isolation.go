@@ -3,13 +3,14 @@ package worker
 import (
 	"bufio"
 	"context"
-	"crypto/ed25519"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"nexus-prover/internal/api"
+	"nexus-prover/internal/telemetry"
 	"nexus-prover/internal/utils"
 	"nexus-prover/pkg/prover"
 	"nexus-prover/pkg/types"
@@ -22,18 +23,6 @@ import (
 	"time"
 )
 
-// ProcessIsolationConfig 进程隔离配置
-type ProcessIsolationConfig struct {
-	NodeIDs           []string `json:"node_ids"`
-	UserID            string   `json:"user_id"`
-	WalletAddress     string   `json:"wallet_address"`
-	RequestDelay      int      `json:"request_delay"`
-	ProverWorkers     int      `json:"prover_workers"`
-	TaskQueueCapacity int      `json:"task_queue_capacity"`
-	MaxLifetime       int      `json:"max_lifetime"` // 秒
-	MaxRestarts       int      `json:"max_restarts"`
-}
-
 // ProcessProverRequest 进程证明请求
 type ProcessProverRequest struct {
 	TaskID       string `json:"task_id"`
@@ -59,10 +48,11 @@ type ProcessProver struct {
 	maxRestarts   int
 	restartCount  int
 	mu            sync.Mutex
+	telemetry     *telemetry.Collector // 记录子进程峰值内存，可为空
 }
 
-// NewProcessProver 创建新的进程证明器
-func NewProcessProver(execPath string, maxLifetime, maxRestarts int) *ProcessProver {
+// NewProcessProver 创建新的进程证明器，maxLifetime 为子进程超时（秒），maxRestarts 为连续失败次数上限
+func NewProcessProver(execPath string, maxLifetime, maxRestarts int, collector *telemetry.Collector) *ProcessProver {
 	memfs := ""
 	memfsNexus := ""
 	memfsExec := execPath
@@ -80,6 +70,7 @@ func NewProcessProver(execPath string, maxLifetime, maxRestarts int) *ProcessPro
 		memfsNexusDir: memfsNexus,
 		maxLifetime:   time.Duration(maxLifetime) * time.Second,
 		maxRestarts:   maxRestarts,
+		telemetry:     collector,
 	}
 }
 
@@ -263,6 +254,13 @@ func (pp *ProcessProver) Prove(task *types.Task) ([]byte, error) {
 		return nil, fmt.Errorf("进程执行失败: %v, 输出: %s", err, string(output))
 	}
 
+	// 记录子进程峰值内存（Linux下 Maxrss 单位为KB）
+	if pp.telemetry != nil && cmd.ProcessState != nil {
+		if ru, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage); ok {
+			pp.telemetry.RecordMemoryUsed(task.TaskID, int64(ru.Maxrss)*1024)
+		}
+	}
+
 	// 读取响应
 	responseFile := filepath.Join(tempDir, "response.json")
 	responseData, err := os.ReadFile(responseFile)
@@ -294,58 +292,73 @@ func (pp *ProcessProver) GetRestartCount() int {
 	return pp.restartCount
 }
 
-// ProcessWorker 进程隔离的worker
-func ProcessWorker(ctx context.Context, id int, priv ed25519.PrivateKey, taskQueue *types.TaskQueue, wg *sync.WaitGroup, prover *ProcessProver) {
+// ProcessWorker 进程隔离的worker，stop 关闭时处理完当前任务后退出（worker池缩容）
+func ProcessWorker(ctx context.Context, apiClient *api.Client, id int, taskQueue *types.TaskQueue, wg *sync.WaitGroup, prover *ProcessProver, stop <-chan struct{}) {
 	defer wg.Done()
 	utils.LogWithTime("[process-worker-%d] 开始进程隔离证明计算", id)
 
+	// 等待任务时响应程序关闭和worker池缩容；证明提交仍使用 ctx，缩容不会中断提交
+	waitCtx, cancelWait := stopContext(ctx, stop)
+	defer cancelWait()
+
 	for {
-		select {
-		case <-ctx.Done():
-			utils.LogWithTime("[process-worker-%d] Shutting down...", id)
-			return
-		default:
-			// 从队列获取任务
-			task, ok := taskQueue.GetTask()
-			if !ok {
-				time.Sleep(1 * time.Second)
-				continue
+		// 从队列获取任务，队列为空时阻塞等待
+		task, err := taskQueue.Next(waitCtx)
+		if err != nil {
+			if ctx.Err() != nil {
+				utils.LogWithTime("[process-worker-%d] Shutting down...", id)
+			} else {
+				utils.LogWithTime("[process-worker-%d] worker池缩容，退出", id)
 			}
+			return
+		}
 
-			utils.LogWithTime("[process-worker-%d] 任务 %s PublicInputs 长度: %d 字节", id, task.TaskID, len(task.PublicInputs))
+		utils.LogWithTime("[process-worker-%d] 任务 %s PublicInputs 长度: %d 字节", id, task.TaskID, len(task.PublicInputs))
 
-			// 使用进程隔离执行证明
-			proof, err := prover.Prove(task)
-			if err != nil {
-				utils.LogWithTime("[process-worker-%d] ❌ 任务 %s 证明计算失败: %v", id, task.TaskID, err)
-				taskQueue.MarkFailed()
-				continue
-			}
+		// 使用进程隔离执行证明
+		proveStart := time.Now()
+		proof, err := prover.Prove(task)
+		if err != nil {
+			utils.LogWithTime("[process-worker-%d] ❌ 任务 %s 证明计算失败: %v", id, task.TaskID, err)
+			taskQueue.MarkFailed()
+			taskQueue.Forget(task) // 不计入去重，服务端再次返回时重新计算
+			continue
+		}
 
-			utils.LogWithTime("[process-worker-%d] 任务 %s Proof 长度: %d 字节", id, task.TaskID, len(proof))
+		utils.LogWithTime("[process-worker-%d] 任务 %s Proof 长度: %d 字节", id, task.TaskID, len(proof))
+		taskQueue.ObserveProveTime(task, time.Since(proveStart))
 
-			// 增加证明计数器
-			incProved()
-			taskQueue.MarkProcessed()
+		// 增加证明计数器
+		incProved()
+		taskQueue.MarkProcessed()
 
-			// 提交证明
-			apiClient := api.NewClient()
-			err = apiClient.SubmitProof(task, proof, priv)
-			if err != nil {
-				if strings.Contains(err.Error(), "NotFoundError") && strings.Contains(err.Error(), "Task not found") && strings.Contains(err.Error(), "httpCode\":404") {
-					utils.LogWithTime("❌ 任务 %s 提交失败(404 NotFound)，直接丢弃: %v", task.TaskID, err)
-					utils.ClearProofData(proof)
-					proof = nil
-				} else {
-					utils.LogWithTime("[process-worker-%d] ❌ 任务 %s 证明提交失败: %v", id, task.TaskID, err)
-					taskQueue.AddRetry(&types.RetryProof{Task: task, Proof: proof, RetryCount: 1})
+		// 提交证明
+		err = apiClient.SubmitProof(ctx, task, proof)
+		if err != nil {
+			var notFound *api.TaskNotFoundError
+			if ctx.Err() != nil {
+				if taskQueue.Suspend(&types.RetryProof{Task: task, Proof: proof, RetryCount: 0}) {
+					utils.LogWithTime("[process-worker-%d] 🛑 程序关闭，任务 %s 提交已中断，证明已保存，重启后重新提交", id, task.TaskID)
+					return
 				}
-			} else {
-				utils.LogWithTime("[process-worker-%d] ✅ 任务 %s 证明提交成功", id, task.TaskID)
-				incSubmitted() // 增加提交成功计数器
+				utils.LogWithTime("[process-worker-%d] 🛑 程序关闭，任务 %s 提交已中断", id, task.TaskID)
+				utils.ClearProofData(proof)
+				return
+			} else if errors.As(err, &notFound) {
+				utils.LogWithTime("❌ 任务 %s 提交失败(404 NotFound)，直接丢弃: %v", task.TaskID, err)
+				taskQueue.Done(task)
 				utils.ClearProofData(proof)
 				proof = nil
+			} else {
+				utils.LogWithTime("[process-worker-%d] ❌ 任务 %s 证明提交失败: %v", id, task.TaskID, err)
+				scheduleRetry(taskQueue, &types.RetryProof{Task: task, Proof: proof}, err)
 			}
+		} else {
+			utils.LogWithTime("[process-worker-%d] ✅ 任务 %s 证明提交成功", id, task.TaskID)
+			incSubmitted() // 增加提交成功计数器
+			taskQueue.Done(task)
+			utils.ClearProofData(proof)
+			proof = nil
 		}
 	}
 }
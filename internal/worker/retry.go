@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"errors"
+	"time"
+
+	"nexus-prover/internal/api"
+	"nexus-prover/internal/config"
+	"nexus-prover/internal/utils"
+	"nexus-prover/pkg/types"
+)
+
+// retryClass 提交失败的错误类型，用于按类型限制重试次数
+func retryClass(err error) string {
+	var rateLimited *api.RateLimitError
+	var serverErr *api.ServerError
+	var decodeErr *api.DecodeError
+	var unauthorized *api.UnauthorizedError
+	var httpErr *api.HTTPError
+	switch {
+	case errors.As(err, &rateLimited):
+		return config.RETRY_CLASS_RATE_LIMIT
+	case errors.As(err, &serverErr), errors.As(err, &decodeErr):
+		return config.RETRY_CLASS_SERVER
+	case errors.As(err, &unauthorized):
+		return config.RETRY_CLASS_UNAUTHORIZED
+	case errors.As(err, &httpErr):
+		return config.RETRY_CLASS_CLIENT
+	default:
+		return config.RETRY_CLASS_NETWORK
+	}
+}
+
+// scheduleRetry 安排提交失败的证明延后重试，不阻塞；该类型的重试次数用完或重试队列已满时放弃，
+// 证明交给死信处理（见 TaskQueue.SetDeadLetter），结束任务并清理证明数据
+func scheduleRetry(taskQueue *types.TaskQueue, rp *types.RetryProof, submitErr error) {
+	rp.Class = retryClass(submitErr)
+	rp.LastError = submitErr.Error()
+	if rp.FailedAt.IsZero() {
+		rp.FailedAt = time.Now()
+	}
+	var minDelay time.Duration
+	var rateLimited *api.RateLimitError
+	if errors.As(submitErr, &rateLimited) {
+		minDelay = rateLimited.RetryAfter
+	}
+
+	err := taskQueue.AddRetry(rp, minDelay)
+	if err == nil {
+		utils.LogWithTime("🔁 任务ID: %s 提交失败(%s)，%s 后第%d次重试: %v",
+			rp.Task.TaskID, rp.Class, time.Until(rp.NextAttempt).Round(time.Second), rp.RetryCount, submitErr)
+		return
+	}
+	if errors.Is(err, types.ErrRetryExhausted) {
+		utils.LogWithTime("❌ 任务ID: %s 提交失败(%s)，已重试%d次，放弃重试，最后错误: %v", rp.Task.TaskID, rp.Class, rp.RetryCount, submitErr)
+	} else {
+		utils.LogWithTime("❌ 任务ID: %s 提交失败(%s)，%v，放弃重试: %v", rp.Task.TaskID, rp.Class, err, submitErr)
+	}
+	taskQueue.GiveUp(rp, err)
+	utils.ClearProofData(rp.Proof)
+	rp.Proof = nil
+}
+
+// drainRetries 纯内存队列关闭时，把仍在等待重试的证明交给死信处理，重启后可用 dlq resubmit 重新提交；
+// 磁盘队列的证明保留在日志中，重启后恢复
+func drainRetries(taskQueue *types.TaskQueue) {
+	if taskQueue.Durable() {
+		return
+	}
+	for _, rp := range taskQueue.DrainRetries() {
+		giveUpOnShutdown(taskQueue, rp)
+	}
+}
+
+// giveUpOnShutdown 程序关闭时放弃纯内存队列中的证明
+func giveUpOnShutdown(taskQueue *types.TaskQueue, rp *types.RetryProof) {
+	utils.LogWithTime("📮 程序关闭，任务ID: %s 已重试%d次，证明转入死信", rp.Task.TaskID, rp.RetryCount)
+	taskQueue.GiveUp(rp, types.ErrRetryShutdown)
+	utils.ClearProofData(rp.Proof)
+	rp.Proof = nil
+}
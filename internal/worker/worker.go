@@ -2,9 +2,10 @@ package worker
 
 import (
 	"context"
-	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -12,6 +13,7 @@ import (
 
 	"nexus-prover/internal/api"
 	"nexus-prover/internal/config"
+	"nexus-prover/internal/telemetry"
 	"nexus-prover/internal/utils"
 	"nexus-prover/pkg/prover"
 	"nexus-prover/pkg/types"
@@ -22,9 +24,6 @@ var totalFetched int64
 var totalProved int64
 var totalSubmitted int64
 
-// 统计间隔时间（秒）
-const STATS_INTERVAL = 60
-
 func incFetched()   { atomic.AddInt64(&totalFetched, 1) }
 func incProved()    { atomic.AddInt64(&totalProved, 1) }
 func incSubmitted() { atomic.AddInt64(&totalSubmitted, 1) }
@@ -44,18 +43,18 @@ func GetStats() (int64, int64, int64) {
 		atomic.LoadInt64(&totalSubmitted)
 }
 
-// TaskFetcher 任务获取worker - 负责从API获取任务并放入队列
-func TaskFetcher(ctx context.Context, nodeIDs []string, pub ed25519.PublicKey, taskQueue *types.TaskQueue, requestDelay int, wg *sync.WaitGroup, acceptingTasks *int32) {
+// TaskFetcher 任务获取worker - 负责从API获取任务并放入队列，每批数量和获取间隔取自 cfg，
+// 节点级覆盖优先；每轮按节点权重从高到低获取，enabled=false 的节点暂停获取；
+// 队列达到高水位时暂停获取，未暂停时本轮可获取的任务数按节点权重分配
+func TaskFetcher(ctx context.Context, apiClient *api.Client, cfg *config.Config, nodes *NodeSet, taskQueue *types.TaskQueue, settings *Settings, wg *sync.WaitGroup, acceptingTasks *int32) {
 	defer wg.Done()
-	utils.LogWithTime("[fetcher] 开始任务获取，节点数: %d", len(nodeIDs))
-
-	// 为每个节点维护独立的状态
-	states := make([]*types.TaskFetchState, len(nodeIDs))
-	for i := range nodeIDs {
-		states[i] = types.NewTaskFetchState()
-	}
+	utils.LogWithTime("[fetcher] 开始任务获取，节点数: %d", nodes.Len())
 
-	apiClient := api.NewClient()
+	// 为每个节点维护独立的状态，节点列表变化时按需创建/清理
+	states := make(map[string]*types.TaskFetchState)
+	parked := make(map[string]bool)
+	queueLogInterval := time.Duration(cfg.QueueLogInterval) * time.Second
+	bp := newBackpressure(taskQueue, cfg.Queue.HighWater, cfg.Queue.LowWaterPercent())
 
 	for {
 		shouldExit := atomic.LoadInt32(acceptingTasks) == 0
@@ -69,20 +68,65 @@ func TaskFetcher(ctx context.Context, nodeIDs []string, pub ed25519.PublicKey, t
 			utils.LogWithTime("[fetcher] Shutting down...")
 			return
 		default:
-			for i, nodeID := range nodeIDs {
-				state := states[i]
+			nodeIDs := nodes.List()
+			current := make(map[string]bool, len(nodeIDs))
+			var due []config.NodeConfig // 本轮到了获取时间的节点
+			for _, node := range nodesByWeight(nodes, nodeIDs, cfg) {
+				nodeID := node.ID
+				current[nodeID] = true
+				if !node.IsEnabled() {
+					if !parked[nodeID] {
+						parked[nodeID] = true
+						utils.LogWithTime("[fetcher@%s] ⏸️ 节点已停用(enabled=false)，暂停获取任务", nodeID)
+					}
+					continue
+				}
+				if parked[nodeID] {
+					delete(parked, nodeID)
+					utils.LogWithTime("[fetcher@%s] ▶️ 节点已启用，恢复获取任务", nodeID)
+				}
+				fetchInterval := time.Duration(node.TaskFetchInterval) * time.Second
+				state, ok := states[nodeID]
+				if !ok {
+					state = types.NewTaskFetchState(fetchInterval, queueLogInterval)
+					states[nodeID] = state
+				} else {
+					state.SetFetchInterval(fetchInterval)
+				}
 				if state.ShouldPrintLog() {
 					state.SetPrintLogTime()
 				}
-				if !state.ShouldFetch() {
+				// 被限流（429 后的 Retry-After 或令牌不足）的节点本轮跳过，不阻塞其他节点的获取
+				if state.ShouldFetch() && apiClient.RateLimiter().Delay(nodeID) == 0 {
+					due = append(due, node)
+				}
+			}
+			// 本轮可获取的任务数按权重分给各节点；暂停或未分到名额时不更新获取时间，下一轮立即获取
+			var shares []int
+			if len(due) > 0 {
+				total := 0
+				for _, node := range due {
+					total += node.BatchSize
+				}
+				shares = weightedShares(due, bp.limit(total))
+			}
+			for i, node := range due {
+				nodeID, state, limit := node.ID, states[node.ID], shares[i]
+				if limit == 0 {
 					continue
 				}
-				tasks, err := apiClient.FetchTaskBatch(nodeID, pub, config.BATCH_SIZE, state)
+				tasks, err := apiClient.FetchTaskBatch(ctx, nodeID, limit, state)
 				if err != nil {
-					if utils.IsRateLimitError(err) {
-						utils.LogWithTime("[fetcher@%s] ⏳ 速率限制，等待下次固定间隔获取", nodeID)
-					} else if strings.Contains(err.Error(), "no task available") ||
-						strings.Contains(err.Error(), "404") {
+					if ctx.Err() != nil {
+						utils.LogWithTime("[fetcher] Shutting down...")
+						return
+					}
+					var rateLimited *api.RateLimitError
+					var noTask *api.NoTaskError
+					if errors.As(err, &rateLimited) {
+						state.SetLastFetchTime() // 限流期间按固定间隔等待，不在下一轮立即重试
+						utils.LogWithTime("[fetcher@%s] ⏳ 速率限制(Retry-After: %s)，等待下次固定间隔获取", nodeID, rateLimited.RetryAfter)
+					} else if errors.As(err, &noTask) {
 						utils.LogWithTime("[fetcher@%s] 💤 无任务可用，等待下次固定间隔获取", nodeID)
 					} else {
 						utils.LogWithTime("[fetcher@%s] ⚠️ 获取任务失败: %v，等待下次固定间隔获取", nodeID, err)
@@ -94,144 +138,215 @@ func TaskFetcher(ctx context.Context, nodeIDs []string, pub ed25519.PublicKey, t
 				}
 				state.SetLastFetchTime()
 
-				added := 0
+				added, duplicates, deferred := 0, 0, 0
 				for _, task := range tasks {
-					incFetched()
-					internalTask := &types.Task{
-						TaskID:       task.TaskId,
-						ProgramID:    task.ProgramId,
-						PublicInputs: task.PublicInputs,
-						NodeID:       nodeID,
-						CreatedAt:    time.Now(),
+					err := taskQueue.AddTask(task)
+					if errors.Is(err, types.ErrDuplicateTask) {
+						// 已分配任务每轮都会返回，已在队列中或最近处理过的不重复计算
+						duplicates++
+						continue
 					}
-					if taskQueue.AddTask(internalTask) {
-						added++
-					} else {
-						utils.LogWithTime("[fetcher@%s] ⚠️ 队列已满，任务 %s 丢弃", nodeID, task.TaskId)
+					if errors.Is(err, types.ErrQueueFull) {
+						// 已分配任务一次全部返回，放不下的仍分配给本节点，下轮再取，不丢弃
+						deferred++
+						continue
 					}
+					incFetched()
+					added++
 				}
-				if added > 0 {
-					utils.LogWithTime("[fetcher@%s] 📥 成功获取并添加 %d 个任务到队列", nodeID, added)
+				if added > 0 || duplicates > 0 {
+					utils.LogWithTime("[fetcher@%s] 📥 成功获取并添加 %d 个任务到队列，跳过重复任务 %d 个", nodeID, added, duplicates)
+				}
+				if deferred > 0 {
+					utils.LogWithTime("[fetcher@%s] 🚦 队列已满，%d 个任务仍分配给本节点，留待下轮获取", nodeID, deferred)
 				}
 			}
-			// 每轮遍历所有节点后等待requestDelay秒, 在配置文件中设置为0
-			if !utils.SleepWithContext(ctx, time.Duration(requestDelay)*time.Second) {
+			for nodeID := range states {
+				if !current[nodeID] {
+					delete(states, nodeID)
+					apiClient.RateLimiter().Forget(nodeID)
+				}
+			}
+			for nodeID := range parked {
+				if !current[nodeID] {
+					delete(parked, nodeID)
+				}
+			}
+			// 每轮遍历所有节点后等待 request_delay 秒，配置热更新后下一轮生效
+			if !utils.SleepWithContext(ctx, settings.RequestDelay()) {
 				return
 			}
 		}
 	}
 }
 
-// ProverWorker 证明计算worker - 从队列获取任务进行计算和提交
-func ProverWorker(ctx context.Context, id int, priv ed25519.PrivateKey, taskQueue *types.TaskQueue, waitSecond int, wg *sync.WaitGroup) {
-	defer wg.Done()
-	utils.LogWithTime("[prover-%d] 开始证明计算", id)
-	// 默认10s
-	if waitSecond == 0 {
-		waitSecond = 10
+// nodesByWeight 填充节点级覆盖的默认值，按权重从高到低排序，权重相同时保持节点列表顺序
+func nodesByWeight(nodes *NodeSet, ids []string, cfg *config.Config) []config.NodeConfig {
+	list := make([]config.NodeConfig, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, nodes.Node(id).WithDefaults(cfg))
 	}
-	apiClient := api.NewClient()
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Weight > list[j].Weight })
+	return list
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			utils.LogWithTime("[prover-%d] Shutting down...", id)
-			return
-		default:
-			// 从队列获取任务
-			task, ok := taskQueue.GetTask()
-			if !ok {
-				// 队列为空，等待一段时间
-				time.Sleep(1 * time.Second)
+// weightedShares 把本轮可获取的 budget 个任务按权重分给各节点（平滑加权轮询，逐个分配），
+// 每个节点不超过自己的 batch_size；队列空间足够时每个节点都取满一批，权重只在空间紧张时决定份额
+func weightedShares(nodes []config.NodeConfig, budget int) []int {
+	shares := make([]int, len(nodes))
+	current := make([]int, len(nodes))
+	for ; budget > 0; budget-- {
+		best, total := -1, 0
+		for i, node := range nodes {
+			if shares[i] >= node.BatchSize {
 				continue
 			}
+			current[i] += node.Weight
+			total += node.Weight
+			if best < 0 || current[i] > current[best] {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		current[best] -= total
+		shares[best]++
+	}
+	return shares
+}
 
-			// 打印 PublicInputs 长度
-			utils.LogWithTime("[prover-%d] 任务 %s PublicInputs 长度: %d 字节", id, task.TaskID, len(task.PublicInputs))
+// ProverWorker 证明计算worker - 从队列获取任务进行计算和提交，stop 关闭时处理完当前任务后退出（worker池缩容）
+func ProverWorker(ctx context.Context, apiClient *api.Client, id int, taskQueue *types.TaskQueue, settings *Settings, collector *telemetry.Collector, wg *sync.WaitGroup, stop <-chan struct{}) {
+	defer wg.Done()
+	utils.LogWithTime("[prover-%d] 开始证明计算", id)
 
-			// 计算证明
-			proof, err := prover.Prove(task, true) // 使用go端本地算法
-			if err != nil {
-				utils.LogWithTime("[prover-%d] ❌ 任务 %s 证明计算失败: %v", id, task.TaskID, err)
-				taskQueue.MarkFailed()
-				continue
+	// 等待任务时响应程序关闭和worker池缩容；证明提交仍使用 ctx，缩容不会中断提交
+	waitCtx, cancelWait := stopContext(ctx, stop)
+	defer cancelWait()
+
+	for {
+		// 从队列获取任务，队列为空时阻塞等待
+		task, err := taskQueue.Next(waitCtx)
+		if err != nil {
+			if ctx.Err() != nil {
+				utils.LogWithTime("[prover-%d] Shutting down...", id)
+			} else {
+				utils.LogWithTime("[prover-%d] worker池缩容，退出", id)
 			}
+			return
+		}
 
-			// 打印 Proof 长度
-			utils.LogWithTime("[prover-%d] 任务 %s Proof 长度: %d 字节", id, task.TaskID, len(proof))
-
-			incProved()
-			taskQueue.MarkProcessed()
-
-			// 提交证明
-			utils.SleepWithContext(ctx, time.Duration(GetRandom(waitSecond))*time.Second) // 计算太快了，提交证明前等待8秒，避免提交过快
-			err = apiClient.SubmitProof(task, proof, priv)
-			if err != nil {
-				if strings.Contains(err.Error(), "NotFoundError") &&
-					strings.Contains(err.Error(), "Task not found") &&
-					strings.Contains(err.Error(), "httpCode\":404") {
-					utils.LogWithTime("❌ 任务 %s 提交失败(404 NotFound)，直接丢弃: %v", task.TaskID, err)
-					// 404错误直接丢弃，清理并释放证明数据
-					utils.ClearProofData(proof)
-					proof = nil
-				} else {
-					taskQueue.AddRetry(&types.RetryProof{Task: task, Proof: proof, RetryCount: 1})
+		// 打印 PublicInputs 长度
+		utils.LogWithTime("[prover-%d] 任务 %s PublicInputs 长度: %d 字节", id, task.TaskID, len(task.PublicInputs))
+
+		// 计算证明
+		proveStart := time.Now()
+		stopMemory := func() int64 { return 0 }
+		if collector != nil {
+			stopMemory = telemetry.TrackPeakMemory(telemetry.MEMORY_SAMPLE_INTERVAL)
+		}
+		proof, err := prover.Prove(task, true) // 使用go端本地算法
+		peakMemory := stopMemory()
+		if err != nil {
+			utils.LogWithTime("[prover-%d] ❌ 任务 %s 证明计算失败: %v", id, task.TaskID, err)
+			taskQueue.MarkFailed()
+			taskQueue.Forget(task) // 不计入去重，服务端再次返回时重新计算
+			continue
+		}
+
+		// 打印 Proof 长度
+		utils.LogWithTime("[prover-%d] 任务 %s Proof 长度: %d 字节", id, task.TaskID, len(proof))
+		taskQueue.ObserveProveTime(task, time.Since(proveStart))
+
+		// 普通模式在本进程内计算，记录证明期间进程物理内存的峰值
+		if collector != nil {
+			collector.RecordMemoryUsed(task.TaskID, peakMemory)
+		}
+
+		incProved()
+		taskQueue.MarkProcessed()
+
+		// 提交证明
+		utils.SleepWithContext(ctx, time.Duration(GetRandom(settings.SubmitWaitSecond()))*time.Second) // 计算太快了，提交证明前等待8秒，避免提交过快
+		err = apiClient.SubmitProof(ctx, task, proof)
+		if err != nil {
+			var notFound *api.TaskNotFoundError
+			if ctx.Err() != nil {
+				if taskQueue.Suspend(&types.RetryProof{Task: task, Proof: proof, RetryCount: 0}) {
+					utils.LogWithTime("[prover-%d] 🛑 程序关闭，任务 %s 提交已中断，证明已保存，重启后重新提交", id, task.TaskID)
+					return
 				}
-			} else {
-				utils.LogWithTime("[prover-%d] ✅ 任务 %s 证明提交成功", id, task.TaskID)
-				incSubmitted() // 增加提交成功计数器
-				// 提交成功后立即清理并释放证明数据
+				utils.LogWithTime("[prover-%d] 🛑 程序关闭，任务 %s 提交已中断", id, task.TaskID)
+				utils.ClearProofData(proof)
+				return
+			} else if errors.As(err, &notFound) {
+				utils.LogWithTime("❌ 任务 %s 提交失败(404 NotFound)，直接丢弃: %v", task.TaskID, err)
+				// 404错误直接丢弃，清理并释放证明数据
+				taskQueue.Done(task)
 				utils.ClearProofData(proof)
 				proof = nil
+			} else {
+				scheduleRetry(taskQueue, &types.RetryProof{Task: task, Proof: proof}, err)
 			}
+		} else {
+			utils.LogWithTime("[prover-%d] ✅ 任务 %s 证明提交成功", id, task.TaskID)
+			incSubmitted() // 增加提交成功计数器
+			taskQueue.Done(task)
+			// 提交成功后立即清理并释放证明数据
+			utils.ClearProofData(proof)
+			proof = nil
 		}
 	}
 }
 
-// RetryWorker 重试worker - 负责从重试队列获取任务并重新提交
-func RetryWorker(ctx context.Context, taskQueue *types.TaskQueue, priv ed25519.PrivateKey, wg *sync.WaitGroup) {
+// RetryWorker 重试worker - 负责从重试队列取出到达提交时间的证明并重新提交，
+// 再次失败时按退避策略重新安排，次数上限见 types.RetryPolicy
+func RetryWorker(ctx context.Context, apiClient *api.Client, taskQueue *types.TaskQueue, wg *sync.WaitGroup) {
 	defer wg.Done()
 	utils.LogWithTime("🔁 启动提交重试worker")
 
-	apiClient := api.NewClient()
-
 	for {
-		select {
-		case <-ctx.Done():
+		rp, err := taskQueue.NextRetry(ctx)
+		if err != nil {
+			drainRetries(taskQueue)
 			utils.LogWithTime("🔁 提交重试worker退出")
 			return
-		default:
-			rp, ok := taskQueue.TryGetRetry()
-			if !ok {
-				time.Sleep(2 * time.Second)
-				continue
-			}
-			err := apiClient.SubmitProof(rp.Task, rp.Proof, priv)
-			if err != nil {
-				if rp.RetryCount < 3 {
-					utils.LogWithTime("🔁 重试提交失败，任务ID: %s，第%d次，放回队列: %v", rp.Task.TaskID, rp.RetryCount, err)
-					rp.RetryCount++
-					taskQueue.AddRetry(rp)
-				} else {
-					utils.LogWithTime("❌ 任务ID: %s 提交重试已达3次，丢弃此任务，最后错误: %v", rp.Task.TaskID, err)
-					// 重试失败后清理并释放证明数据
-					utils.ClearProofData(rp.Proof)
-					rp.Proof = nil
+		}
+		err = apiClient.SubmitProof(ctx, rp.Task, rp.Proof)
+		if err != nil {
+			if ctx.Err() != nil {
+				// 磁盘队列中该证明仍处于等待重新提交状态，重启后恢复
+				utils.LogWithTime("🔁 程序关闭，任务ID: %s 重试提交已中断", rp.Task.TaskID)
+				if !taskQueue.Durable() {
+					giveUpOnShutdown(taskQueue, rp)
 				}
-			} else {
-				utils.LogWithTime("🔁 重试提交成功，任务ID: %s", rp.Task.TaskID)
-				incSubmitted() // 增加提交成功计数器
-				// 重试提交成功后清理并释放证明数据
+				drainRetries(taskQueue)
+				return
+			}
+			var notFound *api.TaskNotFoundError
+			if errors.As(err, &notFound) {
+				utils.LogWithTime("❌ 任务ID: %s 重试提交失败(404 NotFound)，直接丢弃: %v", rp.Task.TaskID, err)
+				taskQueue.Done(rp.Task)
 				utils.ClearProofData(rp.Proof)
 				rp.Proof = nil
+				continue
 			}
+			scheduleRetry(taskQueue, rp, err)
+		} else {
+			utils.LogWithTime("🔁 重试提交成功，任务ID: %s", rp.Task.TaskID)
+			incSubmitted() // 增加提交成功计数器
+			taskQueue.Done(rp.Task)
+			// 重试提交成功后清理并释放证明数据
+			utils.ClearProofData(rp.Proof)
+			rp.Proof = nil
 		}
 	}
 }
 
-// PeriodicStats 周期统计输出函数
-func PeriodicStats(ctx context.Context, taskQueue *types.TaskQueue) {
-	ticker := time.NewTicker(STATS_INTERVAL * time.Second)
+// PeriodicStats 周期统计输出函数，每 intervalSecond 秒输出一次
+func PeriodicStats(ctx context.Context, taskQueue *types.TaskQueue, intervalSecond int) {
+	ticker := time.NewTicker(time.Duration(intervalSecond) * time.Second)
 	defer ticker.Stop()
 
 	lastFetched, lastProved, lastSubmitted := int64(0), int64(0), int64(0)
@@ -243,6 +358,9 @@ func PeriodicStats(ctx context.Context, taskQueue *types.TaskQueue) {
 		case <-ticker.C:
 			currentFetched, currentProved, currentSubmitted := GetStats()
 			queued, processed, failed := taskQueue.GetStats()
+			duplicates := taskQueue.Duplicates()
+			expired := taskQueue.Expired()
+			pendingRetries, retryOverflow, _ := taskQueue.RetryStats()
 
 			// 计算增量
 			fetchedDelta := currentFetched - lastFetched
@@ -250,9 +368,9 @@ func PeriodicStats(ctx context.Context, taskQueue *types.TaskQueue) {
 			submittedDelta := currentSubmitted - lastSubmitted
 
 			// 计算速率（每分钟）
-			fetchedRate := float64(fetchedDelta) / float64(STATS_INTERVAL) * 60
-			provedRate := float64(provedDelta) / float64(STATS_INTERVAL) * 60
-			submittedRate := float64(submittedDelta) / float64(STATS_INTERVAL) * 60
+			fetchedRate := float64(fetchedDelta) / float64(intervalSecond) * 60
+			provedRate := float64(provedDelta) / float64(intervalSecond) * 60
+			submittedRate := float64(submittedDelta) / float64(intervalSecond) * 60
 
 			// 计算成功率
 			var successInfo string
@@ -266,16 +384,33 @@ func PeriodicStats(ctx context.Context, taskQueue *types.TaskQueue) {
 			memMB := utils.GetProcMemUsage()
 			memoryInfo := fmt.Sprintf(" | 进程物理内存: %.2fMB", memMB)
 
-			utils.LogWithTime("📊 周期统计(%ds): 获取%d(+%d,%.1f/min) | 证明%d(+%d,%.1f/min) | 提交%d(+%d,%.1f/min) | 队列:%d 已处理:%d 失败:%d%s%s",
-				STATS_INTERVAL,
+			utils.LogWithTime("📊 周期统计(%ds): 获取%d(+%d,%.1f/min) | 证明%d(+%d,%.1f/min) | 提交%d(+%d,%.1f/min) | 队列:%d 已处理:%d 失败:%d 重复:%d 过期丢弃:%d 待重试:%d 重试溢出:%d%s%s",
+				intervalSecond,
 				currentFetched, fetchedDelta, fetchedRate,
 				currentProved, provedDelta, provedRate,
 				currentSubmitted, submittedDelta, submittedRate,
-				queued, processed, failed,
+				queued, processed, failed, duplicates, expired, pendingRetries, retryOverflow,
 				successInfo, memoryInfo)
+			if depths := taskQueue.NodeDepths(); len(depths) > 1 {
+				utils.LogWithTime("📊 各节点排队任务: %s", formatNodeDepths(depths))
+			}
 
 			// 更新上次统计值
 			lastFetched, lastProved, lastSubmitted = currentFetched, currentProved, currentSubmitted
 		}
 	}
 }
+
+// formatNodeDepths 按节点ID排序输出各节点排队任务数
+func formatNodeDepths(depths map[string]int) string {
+	ids := make([]string, 0, len(depths))
+	for nodeID := range depths {
+		ids = append(ids, nodeID)
+	}
+	sort.Strings(ids)
+	parts := make([]string, 0, len(ids))
+	for _, nodeID := range ids {
+		parts = append(parts, fmt.Sprintf("%s:%d", nodeID, depths[nodeID]))
+	}
+	return strings.Join(parts, " ")
+}
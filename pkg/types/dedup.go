@@ -0,0 +1,97 @@
+package types
+
+import (
+	"container/list"
+	"time"
+)
+
+// 去重缓存默认值
+const (
+	DEFAULT_DEDUP_TTL  = time.Hour // 已结束的任务保留1小时
+	DEFAULT_DEDUP_SIZE = 10000     // 最多保留1万个已结束的任务
+)
+
+// taskState 任务在本进程中的状态
+type taskState int
+
+const (
+	taskQueued  taskState = iota + 1 // 排队中
+	taskProving                      // 正在证明计算
+	taskProved                       // 已计算，等待提交或重新提交
+	taskDone                         // 已结束（提交成功、丢弃或放弃），保留 TTL 时间
+)
+
+// dedupItem 去重缓存中的一个任务
+type dedupItem struct {
+	state   taskState
+	expires time.Time     // 只对已结束的任务有效
+	elem    *list.Element // 已结束任务在 done 链表中的位置
+}
+
+// dedupCache 任务ID去重缓存：排队中、计算中和等待提交的任务一直保留，
+// 已结束的任务保留 ttl 时间，数量超过 size 时淘汰最早结束的任务；调用方持有队列锁
+type dedupCache struct {
+	ttl   time.Duration
+	size  int
+	items map[string]*dedupItem
+	done  *list.List // 已结束的任务ID，按结束时间排列
+}
+
+func newDedupCache(ttl time.Duration, size int) *dedupCache {
+	return &dedupCache{ttl: ttl, size: size, items: make(map[string]*dedupItem), done: list.New()}
+}
+
+// seen 任务ID是否已在处理中或最近已结束
+func (c *dedupCache) seen(taskID string, now time.Time) bool {
+	c.expire(now)
+	_, ok := c.items[taskID]
+	return ok
+}
+
+// set 更新任务状态，进入已结束状态时开始计算 TTL
+func (c *dedupCache) set(taskID string, state taskState, now time.Time) {
+	item, ok := c.items[taskID]
+	if !ok {
+		item = &dedupItem{}
+		c.items[taskID] = item
+	}
+	if item.elem != nil {
+		c.done.Remove(item.elem)
+		item.elem = nil
+	}
+	item.state = state
+	if state == taskDone {
+		item.expires = now.Add(c.ttl)
+		item.elem = c.done.PushBack(taskID)
+		for c.done.Len() > c.size {
+			c.evict(c.done.Front())
+		}
+	}
+}
+
+// forget 删除任务ID，之后同一任务可以重新入队
+func (c *dedupCache) forget(taskID string) {
+	item, ok := c.items[taskID]
+	if !ok {
+		return
+	}
+	if item.elem != nil {
+		c.done.Remove(item.elem)
+	}
+	delete(c.items, taskID)
+}
+
+// expire 清除过期的已结束任务
+func (c *dedupCache) expire(now time.Time) {
+	for e := c.done.Front(); e != nil; e = c.done.Front() {
+		if c.items[e.Value.(string)].expires.After(now) {
+			return
+		}
+		c.evict(e)
+	}
+}
+
+func (c *dedupCache) evict(e *list.Element) {
+	delete(c.items, e.Value.(string))
+	c.done.Remove(e)
+}
@@ -0,0 +1,176 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestDedupCache 测试进行中的任务一直保留，已结束的任务按 TTL 过期、按数量淘汰最早结束的
+func TestDedupCache(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		size   int
+		steps  func(c *dedupCache)
+		at     time.Duration // 在 now 之后多久检查
+		seen   []string
+		unseen []string
+	}{
+		{
+			name: "TTL 内已结束的任务",
+			size: 10,
+			steps: func(c *dedupCache) {
+				c.set("a", taskQueued, now)
+				c.set("a", taskDone, now)
+			},
+			at:   59 * time.Minute,
+			seen: []string{"a"},
+		},
+		{
+			name: "TTL 后过期",
+			size: 10,
+			steps: func(c *dedupCache) {
+				c.set("a", taskDone, now)
+				c.set("b", taskDone, now.Add(30*time.Minute))
+			},
+			at:     61 * time.Minute,
+			seen:   []string{"b"},
+			unseen: []string{"a"},
+		},
+		{
+			name: "进行中的任务不过期",
+			size: 10,
+			steps: func(c *dedupCache) {
+				c.set("a", taskQueued, now)
+				c.set("b", taskProving, now)
+				c.set("c", taskProved, now)
+			},
+			at:   24 * time.Hour,
+			seen: []string{"a", "b", "c"},
+		},
+		{
+			name: "超过数量淘汰最早结束的",
+			size: 2,
+			steps: func(c *dedupCache) {
+				c.set("a", taskDone, now)
+				c.set("b", taskDone, now.Add(time.Second))
+				c.set("c", taskProving, now)
+				c.set("d", taskDone, now.Add(2*time.Second))
+			},
+			seen:   []string{"b", "c", "d"},
+			unseen: []string{"a"},
+		},
+		{
+			name: "重新结束的任务移到末尾",
+			size: 2,
+			steps: func(c *dedupCache) {
+				c.set("a", taskDone, now)
+				c.set("b", taskDone, now)
+				c.set("a", taskDone, now.Add(time.Second))
+				c.set("c", taskDone, now.Add(time.Second))
+			},
+			seen:   []string{"a", "c"},
+			unseen: []string{"b"},
+		},
+		{
+			name: "forget 删除任务",
+			size: 10,
+			steps: func(c *dedupCache) {
+				c.set("a", taskDone, now)
+				c.set("b", taskProving, now)
+				c.forget("a")
+				c.forget("b")
+				c.forget("x")
+			},
+			unseen: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newDedupCache(time.Hour, tt.size)
+			tt.steps(c)
+			at := now.Add(tt.at)
+			for _, id := range tt.seen {
+				if !c.seen(id, at) {
+					t.Errorf("%s 应在去重缓存中", id)
+				}
+			}
+			for _, id := range tt.unseen {
+				if c.seen(id, at) {
+					t.Errorf("%s 不应在去重缓存中", id)
+				}
+			}
+			if c.done.Len() > tt.size {
+				t.Errorf("已结束的任务 %d 个, 超过上限 %d", c.done.Len(), tt.size)
+			}
+		})
+	}
+}
+
+// TestTaskQueueDedup 测试重复入队被拒绝，提交结束的任务去重期内不再入队，证明计算失败的任务可以重新入队
+func TestTaskQueueDedup(t *testing.T) {
+	tq := NewTaskQueue(10, 10)
+	if err := tq.AddTask(testTask("t1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tq.AddTask(testTask("t1")); !errors.Is(err, ErrDuplicateTask) {
+		t.Errorf("排队中的任务重复入队: %v", err)
+	}
+	task, err := tq.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tq.AddTask(testTask("t1")); !errors.Is(err, ErrDuplicateTask) {
+		t.Errorf("计算中的任务重复入队: %v", err)
+	}
+	tq.Forget(task)
+	if err := tq.AddTask(testTask("t1")); err != nil {
+		t.Errorf("证明计算失败后应能重新入队: %v", err)
+	}
+	task, _ = tq.Next(context.Background())
+	tq.Done(task)
+	if err := tq.AddTask(testTask("t1")); !errors.Is(err, ErrDuplicateTask) {
+		t.Errorf("已结束的任务在去重期内重复入队: %v", err)
+	}
+	if tq.Duplicates() != 3 {
+		t.Errorf("重复任务数 = %d, 期望 3", tq.Duplicates())
+	}
+}
+
+// TestForgetWAL 测试证明计算失败的任务重启后不再恢复
+func TestForgetWAL(t *testing.T) {
+	dir := t.TempDir()
+	tq, _, err := OpenTaskQueue(10, 10, WALOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tq.AddTask(testTask("t1"))
+	tq.AddTask(testTask("t2"))
+	task, _ := tq.GetTask()
+	tq.Forget(task)
+	tq.Close()
+
+	tq, recovered, err := OpenTaskQueue(10, 10, WALOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tq.Close()
+	if recovered.Queued != 1 || recovered.InFlight != 0 {
+		t.Errorf("恢复统计 = %+v, 期望只恢复 t2", recovered)
+	}
+}
+
+// TestOnDone 测试任务结束和计算失败时都回调 SetOnDone
+func TestOnDone(t *testing.T) {
+	tq := NewTaskQueue(10, 10)
+	var done []string
+	tq.SetOnDone(func(task *Task) { done = append(done, task.TaskID) })
+	tq.Done(&Task{TaskID: "submitted"})
+	tq.Forget(&Task{TaskID: "failed"})
+	tq.GiveUp(&RetryProof{Task: &Task{TaskID: "dead"}}, ErrRetryExhausted)
+	if len(done) != 3 || done[0] != "submitted" || done[1] != "failed" || done[2] != "dead" {
+		t.Errorf("回调 = %v", done)
+	}
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTaskExpired 任务已超过有效期
+var ErrTaskExpired = errors.New("任务已过期")
+
+// ErrTaskWouldExpire 按预估证明耗时无法在任务过期前完成
+var ErrTaskWouldExpire = errors.New("预估无法在任务过期前完成证明")
+
+// ExpiryPolicy 任务有效期：从 Task.CreatedAt（服务端创建时间或获取时间）算起，0 表示不限制
+type ExpiryPolicy struct {
+	TTL        time.Duration            // 默认有效期
+	ProgramTTL map[string]time.Duration // 按程序ID覆盖
+	OnDrop     func(task *Task, reason error)
+}
+
+// droppedTask 出队时因过期被丢弃的任务
+type droppedTask struct {
+	task   *Task
+	reason error
+}
+
+// SetExpiry 设置任务有效期，出队时丢弃已过期的任务，以及按预估证明耗时（见 SetCostEstimator）无法在过期前完成的任务
+func (tq *TaskQueue) SetExpiry(policy ExpiryPolicy) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.expiry = policy
+}
+
+// Deadline 任务的过期时间，没有有效期时返回 false
+func (tq *TaskQueue) Deadline(task *Task) (time.Time, bool) {
+	tq.mu.RLock()
+	defer tq.mu.RUnlock()
+	return tq.deadline(task)
+}
+
+// deadline 见 Deadline（调用方持锁）
+func (tq *TaskQueue) deadline(task *Task) (time.Time, bool) {
+	ttl, ok := tq.expiry.ProgramTTL[task.ProgramID]
+	if !ok {
+		ttl = tq.expiry.TTL
+	}
+	if ttl <= 0 {
+		return time.Time{}, false
+	}
+	return task.CreatedAt.Add(ttl), true
+}
+
+// checkExpiry 检查任务能否在过期前完成（调用方持锁）
+func (tq *TaskQueue) checkExpiry(task *Task, now time.Time) error {
+	deadline, ok := tq.deadline(task)
+	if !ok {
+		return nil
+	}
+	if !now.Before(deadline) {
+		return ErrTaskExpired
+	}
+	if tq.costs != nil && now.Add(tq.costs.Estimate(task.ProgramID)).After(deadline) {
+		return ErrTaskWouldExpire
+	}
+	return nil
+}
+
+// drop 处理出队时丢弃的任务：计数、结束任务并回调（调用方不持锁）
+func (tq *TaskQueue) drop(dropped []droppedTask) {
+	if len(dropped) == 0 {
+		return
+	}
+	tq.mu.RLock()
+	onDrop := tq.expiry.OnDrop
+	tq.mu.RUnlock()
+	for _, d := range dropped {
+		atomic.AddInt64(&tq.stats.expired, 1)
+		tq.Done(d.task)
+		if onDrop != nil {
+			onDrop(d.task, d.reason)
+		}
+	}
+}
+
+// Expired 因过期（或预估无法按时完成）被丢弃的任务数，不计入失败
+func (tq *TaskQueue) Expired() int64 {
+	return atomic.LoadInt64(&tq.stats.expired)
+}
@@ -0,0 +1,128 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestExpiryDrop 测试出队时丢弃已过期和预估无法按时完成的任务，计入 Expired 而不计入失败
+func TestExpiryDrop(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		policy  ExpiryPolicy
+		costs   map[string]time.Duration
+		age     time.Duration
+		program string
+		reason  error // nil 表示正常出队
+	}{
+		{"未设置有效期", ExpiryPolicy{}, nil, 24 * time.Hour, "p", nil},
+		{"有效期内", ExpiryPolicy{TTL: time.Minute}, nil, 30 * time.Second, "p", nil},
+		{"已过期", ExpiryPolicy{TTL: time.Minute}, nil, 2 * time.Minute, "p", ErrTaskExpired},
+		{"预估无法按时完成", ExpiryPolicy{TTL: time.Minute}, map[string]time.Duration{"p": 45 * time.Second}, 30 * time.Second, "p", ErrTaskWouldExpire},
+		{"预估可以按时完成", ExpiryPolicy{TTL: time.Minute}, map[string]time.Duration{"p": 20 * time.Second}, 30 * time.Second, "p", nil},
+		{"按程序覆盖为更短", ExpiryPolicy{TTL: time.Hour, ProgramTTL: map[string]time.Duration{"p": time.Minute}}, nil, 2 * time.Minute, "p", ErrTaskExpired},
+		{"按程序覆盖为更长", ExpiryPolicy{TTL: time.Minute, ProgramTTL: map[string]time.Duration{"p": time.Hour}}, nil, 2 * time.Minute, "p", nil},
+		{"按程序覆盖为不限制", ExpiryPolicy{TTL: time.Minute, ProgramTTL: map[string]time.Duration{"p": 0}}, nil, 2 * time.Minute, "p", nil},
+		{"其他程序使用默认有效期", ExpiryPolicy{TTL: time.Minute, ProgramTTL: map[string]time.Duration{"p": time.Hour}}, nil, 2 * time.Minute, "q", ErrTaskExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tq := NewTaskQueue(10, 10)
+			if tt.costs != nil {
+				tq.SetCostEstimator(NewCostEstimator(tt.costs, time.Second))
+			}
+			var dropped []error
+			tt.policy.OnDrop = func(task *Task, reason error) {
+				dropped = append(dropped, reason)
+			}
+			tq.SetExpiry(tt.policy)
+			tq.AddTask(&Task{TaskID: "t1", ProgramID: tt.program, CreatedAt: now.Add(-tt.age)})
+
+			task, ok := tq.GetTask()
+			if tt.reason == nil {
+				if !ok || task.TaskID != "t1" || tq.Expired() != 0 || len(dropped) != 0 {
+					t.Errorf("期望正常出队: %v, %v, 丢弃 %v", task, ok, dropped)
+				}
+				return
+			}
+			if ok {
+				t.Fatalf("过期任务不应出队: %v", task)
+			}
+			if len(dropped) != 1 || !errors.Is(dropped[0], tt.reason) {
+				t.Errorf("丢弃原因 = %v, 期望 %v", dropped, tt.reason)
+			}
+			if tq.Expired() != 1 || tq.Len() != 0 {
+				t.Errorf("Expired = %d, Len = %d", tq.Expired(), tq.Len())
+			}
+			if _, _, failed := tq.GetStats(); failed != 0 {
+				t.Errorf("过期任务不应计入失败: %d", failed)
+			}
+			// 丢弃的任务结束，去重期内再次获取到时跳过
+			if err := tq.AddTask(&Task{TaskID: "t1", ProgramID: tt.program, CreatedAt: now}); !errors.Is(err, ErrDuplicateTask) {
+				t.Errorf("再次入队: 错误 = %v, 期望 ErrDuplicateTask", err)
+			}
+		})
+	}
+}
+
+// TestExpirySkip 测试出队时跳过排在前面的过期任务，取到后面有效的任务
+func TestExpirySkip(t *testing.T) {
+	now := time.Now()
+	tq := NewTaskQueue(10, 10)
+	tq.SetExpiry(ExpiryPolicy{TTL: time.Minute})
+	tq.AddTask(&Task{TaskID: "old1", CreatedAt: now.Add(-2 * time.Minute)})
+	tq.AddTask(&Task{TaskID: "old2", CreatedAt: now.Add(-time.Hour)})
+	tq.AddTask(&Task{TaskID: "fresh", CreatedAt: now})
+
+	task, ok := tq.GetTask()
+	if !ok || task.TaskID != "fresh" {
+		t.Fatalf("GetTask = %v, %v, 期望 fresh", task, ok)
+	}
+	if tq.Expired() != 2 || tq.Len() != 0 {
+		t.Errorf("Expired = %d, Len = %d", tq.Expired(), tq.Len())
+	}
+}
+
+// TestExpiryNext 测试 Next 被唤醒后任务都已过期时继续等待，拿到之后入队的有效任务
+func TestExpiryNext(t *testing.T) {
+	now := time.Now()
+	tq := NewTaskQueue(10, 10)
+	var mu sync.Mutex
+	var dropped []string
+	tq.SetExpiry(ExpiryPolicy{TTL: time.Minute, OnDrop: func(task *Task, reason error) {
+		mu.Lock()
+		dropped = append(dropped, task.TaskID)
+		mu.Unlock()
+	}})
+
+	result := make(chan *Task, 1)
+	go func() {
+		task, err := tq.Next(context.Background())
+		if err != nil {
+			t.Error(err)
+		}
+		result <- task
+	}()
+	waitIdle(t, tq, 1)
+	tq.AddTask(&Task{TaskID: "old", CreatedAt: now.Add(-2 * time.Minute)})
+	waitIdle(t, tq, 1)
+	tq.AddTask(&Task{TaskID: "fresh", CreatedAt: now})
+
+	select {
+	case task := <-result:
+		if task.TaskID != "fresh" {
+			t.Errorf("Next = %s, 期望 fresh", task.TaskID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Next 没有拿到有效任务")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(dropped) != 1 || dropped[0] != "old" || tq.Expired() != 1 {
+		t.Errorf("丢弃 = %v, Expired = %d", dropped, tq.Expired())
+	}
+}
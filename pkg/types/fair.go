@@ -0,0 +1,170 @@
+package types
+
+import "time"
+
+// fairQueue 按节点公平出队（加权差额轮询）：每个有排队任务的节点是一个逻辑子队列，轮到的节点获得
+// 权重×基准额度的证明时间，出队任务按预估证明耗时（见 SetCostEstimator）扣减，额度不足时轮到下一个节点；
+// 长期来看各节点占用的证明时间与权重成正比，一个节点一次获取大量任务不会让其他节点长时间等待
+type fairQueue struct {
+	weights map[string]int     // 节点ID => 权重，未配置的节点为1
+	ring    []string           // 有排队任务的节点，按开始排队的先后排列
+	cursor  int                // 当前轮到的节点
+	fresh   bool               // 当前节点本轮尚未获得额度
+	deficit map[string]float64 // 节点剩余额度（秒）
+}
+
+// SetFairness 开启按节点公平出队，weights 为节点权重（未列出的节点为1，空表示轮询）；nil 关闭。
+// 节点内部仍按先进先出或打分函数（SetScorer）出队；运行中可以再次调用更新权重
+func (tq *TaskQueue) SetFairness(weights map[string]int) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	if weights == nil {
+		tq.fair = nil
+		return
+	}
+	if tq.fair == nil {
+		tq.fair = &fairQueue{fresh: true, deficit: make(map[string]float64)}
+		for _, task := range tq.tasks {
+			if _, ok := tq.fair.deficit[task.NodeID]; !ok {
+				tq.fair.join(task.NodeID)
+			}
+		}
+	}
+	tq.fair.weights = make(map[string]int, len(weights))
+	for nodeID, w := range weights {
+		tq.fair.weights[nodeID] = w
+	}
+}
+
+// NodeDepths 各节点排队中的任务数
+func (tq *TaskQueue) NodeDepths() map[string]int {
+	tq.mu.RLock()
+	defer tq.mu.RUnlock()
+	depths := make(map[string]int, len(tq.depths))
+	for nodeID, n := range tq.depths {
+		depths[nodeID] = n
+	}
+	return depths
+}
+
+// push 任务加入队尾（调用方持锁）
+func (tq *TaskQueue) push(task *Task) {
+	tq.tasks = append(tq.tasks, task)
+	tq.depths[task.NodeID]++
+	if tq.fair != nil && tq.depths[task.NodeID] == 1 {
+		tq.fair.join(task.NodeID)
+	}
+}
+
+// remove 移出第 i 个任务（调用方持锁）
+func (tq *TaskQueue) remove(i int) {
+	task := tq.tasks[i]
+	copy(tq.tasks[i:], tq.tasks[i+1:])
+	tq.tasks[len(tq.tasks)-1] = nil
+	tq.tasks = tq.tasks[:len(tq.tasks)-1]
+	if tq.depths[task.NodeID]--; tq.depths[task.NodeID] == 0 {
+		delete(tq.depths, task.NodeID)
+		if tq.fair != nil {
+			tq.fair.leave(task.NodeID)
+		}
+	}
+}
+
+// taskCost 任务的预估证明耗时（秒），没有耗时预估时每个任务计1
+func (tq *TaskQueue) taskCost(task *Task) float64 {
+	if tq.costs == nil {
+		return 1
+	}
+	if cost := tq.costs.Estimate(task.ProgramID).Seconds(); cost > 0 {
+		return cost
+	}
+	return 1
+}
+
+// nextFair 公平模式下一个出队任务的下标（调用方持锁，队列非空）：从当前节点开始，
+// 额度够出队该节点的下一个任务时选中，否则轮到下一个节点并为其补充额度
+func (tq *TaskQueue) nextFair(now time.Time) int {
+	f := tq.fair
+	heads := tq.nodeHeads(now)
+	quantum := 0.0 // 基准额度: 各节点下一个任务预估耗时的最大值，保证每次轮到时至少能出队一个任务
+	for _, i := range heads {
+		if cost := tq.taskCost(tq.tasks[i]); cost > quantum {
+			quantum = cost
+		}
+	}
+	for {
+		nodeID := f.ring[f.cursor]
+		if f.fresh {
+			f.deficit[nodeID] += float64(f.weight(nodeID)) * quantum
+			f.fresh = false
+		}
+		i := heads[nodeID]
+		if f.deficit[nodeID] >= tq.taskCost(tq.tasks[i]) {
+			return i
+		}
+		f.cursor = (f.cursor + 1) % len(f.ring)
+		f.fresh = true
+	}
+}
+
+// nodeHeads 各节点下一个出队任务的下标（调用方持锁）：节点内先进先出，或分数最高、分数相同时先入队的
+func (tq *TaskQueue) nodeHeads(now time.Time) map[string]int {
+	heads := make(map[string]int, len(tq.depths))
+	scores := make(map[string]float64)
+	for i, task := range tq.tasks {
+		_, ok := heads[task.NodeID]
+		if tq.scorer == nil {
+			if !ok {
+				heads[task.NodeID] = i
+			}
+			continue
+		}
+		score := tq.scorer(task, now)
+		if !ok || score > scores[task.NodeID] {
+			heads[task.NodeID], scores[task.NodeID] = i, score
+		}
+	}
+	return heads
+}
+
+// charge 任务出队计算，扣减所属节点的额度（调用方持锁，在 remove 之前调用）
+func (tq *TaskQueue) charge(task *Task) {
+	if tq.fair != nil {
+		tq.fair.deficit[task.NodeID] -= tq.taskCost(task)
+	}
+}
+
+// weight 节点权重
+func (f *fairQueue) weight(nodeID string) int {
+	if w, ok := f.weights[nodeID]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// join 节点开始有排队任务，排到轮询末尾
+func (f *fairQueue) join(nodeID string) {
+	f.ring = append(f.ring, nodeID)
+	f.deficit[nodeID] = 0
+}
+
+// leave 节点的任务全部出队，退出轮询并清空额度
+func (f *fairQueue) leave(nodeID string) {
+	for i, id := range f.ring {
+		if id != nodeID {
+			continue
+		}
+		f.ring = append(f.ring[:i], f.ring[i+1:]...)
+		switch {
+		case i < f.cursor:
+			f.cursor--
+		case i == f.cursor:
+			f.fresh = true // 下一个节点接着轮
+		}
+		if f.cursor >= len(f.ring) {
+			f.cursor = 0
+		}
+		break
+	}
+	delete(f.deficit, nodeID)
+}
@@ -0,0 +1,129 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// drainNodes 依次取出全部任务，返回出队任务所属节点的序列
+func drainNodes(t *testing.T, tq *TaskQueue) string {
+	t.Helper()
+	var order []string
+	for {
+		task, ok := tq.GetTask()
+		if !ok {
+			break
+		}
+		order = append(order, task.NodeID)
+	}
+	return strings.Join(order, "")
+}
+
+// addNodeTasks 为节点依次添加 n 个任务
+func addNodeTasks(t *testing.T, tq *TaskQueue, nodeID, programID string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		task := &Task{TaskID: fmt.Sprintf("%s-%d", nodeID, i), NodeID: nodeID, ProgramID: programID, CreatedAt: time.Now()}
+		if err := tq.AddTask(task); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestFairQueue 测试按节点权重轮询出队，一个节点排入大量任务时其他节点不会被饿死
+func TestFairQueue(t *testing.T) {
+	type batch struct {
+		nodeID  string
+		program string
+		n       int
+	}
+	tests := []struct {
+		name    string
+		weights map[string]int
+		costs   map[string]time.Duration
+		batches []batch
+		want    string
+	}{
+		{
+			name:    "权重2比1",
+			weights: map[string]int{"A": 2, "B": 1},
+			batches: []batch{{"A", "p", 20}, {"B", "p", 3}},
+			want:    "AAB" + "AAB" + "AAB" + strings.Repeat("A", 14),
+		},
+		{
+			name:    "未配置权重时轮询",
+			weights: map[string]int{},
+			batches: []batch{{"A", "p", 10}, {"B", "p", 2}, {"C", "p", 2}},
+			want:    "ABC" + "ABC" + strings.Repeat("A", 8),
+		},
+		{
+			name:    "按预估耗时扣减额度",
+			weights: map[string]int{},
+			costs:   map[string]time.Duration{"slow": 2 * time.Second, "fast": time.Second},
+			batches: []batch{{"A", "slow", 3}, {"B", "fast", 6}},
+			want:    "ABB" + "ABB" + "ABB",
+		},
+		{
+			name:    "当前节点离开后轮到下一个节点",
+			weights: map[string]int{},
+			batches: []batch{{"A", "p", 1}, {"B", "p", 2}, {"C", "p", 2}},
+			want:    "ABCBC",
+		},
+		{
+			name:    "末尾节点离开后回到队首",
+			weights: map[string]int{},
+			batches: []batch{{"A", "p", 3}, {"B", "p", 1}},
+			want:    "ABAA",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tq := NewTaskQueue(100, 10)
+			if tt.costs != nil {
+				tq.SetCostEstimator(NewCostEstimator(tt.costs, time.Second))
+			}
+			tq.SetFairness(tt.weights)
+			for _, b := range tt.batches {
+				addNodeTasks(t, tq, b.nodeID, b.program, b.n)
+			}
+			if got := drainNodes(t, tq); got != tt.want {
+				t.Errorf("出队顺序 = %s, 期望 %s", got, tt.want)
+			}
+			if len(tq.fair.ring) != 0 || len(tq.fair.deficit) != 0 {
+				t.Errorf("全部出队后 ring = %v, deficit = %v", tq.fair.ring, tq.fair.deficit)
+			}
+		})
+	}
+}
+
+// TestFairQueueLeaveAtCursor 测试轮到的节点任务出完离开时游标仍然有效，重新排队的节点排到末尾且额度清零
+func TestFairQueueLeaveAtCursor(t *testing.T) {
+	tq := NewTaskQueue(100, 10)
+	tq.SetFairness(map[string]int{"A": 2})
+	addNodeTasks(t, tq, "A", "p", 1)
+	addNodeTasks(t, tq, "B", "p", 2)
+	addNodeTasks(t, tq, "C", "p", 2)
+
+	// A 有 2 份额度但只有 1 个任务，出队后在游标处离开
+	if task, _ := tq.GetTask(); task.NodeID != "A" {
+		t.Fatalf("第一个任务来自 %s, 期望 A", task.NodeID)
+	}
+	f := tq.fair
+	if strings.Join(f.ring, "") != "BC" || f.cursor != 0 || !f.fresh {
+		t.Fatalf("A 离开后 ring = %v, cursor = %d, fresh = %v", f.ring, f.cursor, f.fresh)
+	}
+	if _, ok := f.deficit["A"]; ok {
+		t.Error("离开的节点应清空额度")
+	}
+
+	// A 重新排队，排到末尾，不继承上次剩余的额度
+	tq.AddTask(&Task{TaskID: "A-again", NodeID: "A", ProgramID: "p"})
+	if strings.Join(f.ring, "") != "BCA" || f.deficit["A"] != 0 {
+		t.Fatalf("A 重新排队后 ring = %v, deficit = %v", f.ring, f.deficit["A"])
+	}
+	if got := drainNodes(t, tq); got != "BCABC" {
+		t.Errorf("出队顺序 = %s, 期望 BCABC", got)
+	}
+}
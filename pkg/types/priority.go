@@ -0,0 +1,74 @@
+package types
+
+import (
+	"sync"
+	"time"
+)
+
+// TaskScorer 任务优先级打分函数，分数高的任务先出队；出队时对排队中的全部任务重新打分，可以依赖当前时间
+type TaskScorer func(task *Task, now time.Time) float64
+
+// PriorityWeights 默认打分函数的权重，权重为0的因素不计入
+type PriorityWeights struct {
+	Age      float64            // 任务年龄（距服务端创建时间）每秒加分，越老越先计算，避免在队列中过期
+	Existing float64            // 已分配给本节点的任务（GetExistingTasks）加分
+	Programs map[string]float64 // 按程序ID加分
+	Cost     float64            // 预估证明耗时每秒扣分，耗时短的任务先计算
+}
+
+// NewScorer 创建默认打分函数:
+// 分数 = 年龄秒数×Age + 已分配任务×Existing + Programs[程序ID] - 预估耗时秒数×Cost
+func NewScorer(w PriorityWeights, costs *CostEstimator) TaskScorer {
+	return func(task *Task, now time.Time) float64 {
+		score := now.Sub(task.CreatedAt).Seconds() * w.Age
+		if task.Existing {
+			score += w.Existing
+		}
+		score += w.Programs[task.ProgramID]
+		if costs != nil && w.Cost != 0 {
+			score -= costs.Estimate(task.ProgramID).Seconds() * w.Cost
+		}
+		return score
+	}
+}
+
+// costSmoothing 实际耗时的指数移动平均系数
+const costSmoothing = 0.3
+
+// CostEstimator 按程序ID预估证明耗时：初始值来自配置，每次证明完成后按实际耗时修正（指数移动平均）
+type CostEstimator struct {
+	mu       sync.Mutex
+	fallback time.Duration            // 没有配置也没有观测值的程序
+	costs    map[string]time.Duration // 程序ID => 预估耗时
+}
+
+// NewCostEstimator 创建耗时预估，initial 为各程序的初始预估，fallback 为未知程序的预估
+func NewCostEstimator(initial map[string]time.Duration, fallback time.Duration) *CostEstimator {
+	costs := make(map[string]time.Duration, len(initial))
+	for programID, d := range initial {
+		costs[programID] = d
+	}
+	return &CostEstimator{fallback: fallback, costs: costs}
+}
+
+// Observe 记录一次实际证明耗时
+func (e *CostEstimator) Observe(programID string, d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	prev, ok := e.costs[programID]
+	if !ok {
+		e.costs[programID] = d
+		return
+	}
+	e.costs[programID] = time.Duration(costSmoothing*float64(d) + (1-costSmoothing)*float64(prev))
+}
+
+// Estimate 程序的预估证明耗时
+func (e *CostEstimator) Estimate(programID string) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if d, ok := e.costs[programID]; ok {
+		return d
+	}
+	return e.fallback
+}
@@ -0,0 +1,134 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestScorerOrder 测试默认打分函数的出队顺序，分数相同时先入队的先出队
+func TestScorerOrder(t *testing.T) {
+	now := time.Now()
+	costs := map[string]time.Duration{"slow": 60 * time.Second, "fast": 10 * time.Second}
+	type task struct {
+		id       string
+		program  string
+		age      time.Duration
+		existing bool
+	}
+	tests := []struct {
+		name    string
+		weights PriorityWeights
+		tasks   []task
+		want    string
+	}{
+		{
+			name:    "年龄大的先出队",
+			weights: PriorityWeights{Age: 1},
+			tasks:   []task{{"a", "p", 10 * time.Second, false}, {"b", "p", 30 * time.Second, false}, {"c", "p", 20 * time.Second, false}},
+			want:    "bca",
+		},
+		{
+			name:    "已分配任务加分",
+			weights: PriorityWeights{Age: 1, Existing: 100},
+			tasks:   []task{{"a", "p", 50 * time.Second, false}, {"b", "p", 0, true}},
+			want:    "ba",
+		},
+		{
+			name:    "按程序加分",
+			weights: PriorityWeights{Programs: map[string]float64{"fast": 5}},
+			tasks:   []task{{"a", "slow", 0, false}, {"b", "fast", 0, false}},
+			want:    "ba",
+		},
+		{
+			name:    "预估耗时短的先出队",
+			weights: PriorityWeights{Cost: 1},
+			tasks:   []task{{"a", "slow", 0, false}, {"b", "unknown", 0, false}, {"c", "fast", 0, false}},
+			want:    "cba",
+		},
+		{
+			name:    "耗时扣分与年龄加分抵消",
+			weights: PriorityWeights{Age: 1, Cost: 1},
+			tasks:   []task{{"a", "fast", 0, false}, {"b", "slow", 55 * time.Second, false}},
+			want:    "ba",
+		},
+		{
+			name:    "分数相同时先进先出",
+			weights: PriorityWeights{Existing: 10},
+			tasks:   []task{{"a", "p", 0, false}, {"b", "p", 0, true}, {"c", "p", 0, false}, {"d", "p", 0, true}},
+			want:    "bdac",
+		},
+		{
+			name:    "权重为0时先进先出",
+			weights: PriorityWeights{},
+			tasks:   []task{{"a", "slow", 0, true}, {"b", "fast", 30 * time.Second, false}, {"c", "p", 0, false}},
+			want:    "abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimator := NewCostEstimator(costs, 30*time.Second)
+			tq := NewTaskQueue(10, 10)
+			tq.SetScorer(NewScorer(tt.weights, estimator))
+			for _, task := range tt.tasks {
+				tq.AddTask(&Task{TaskID: task.id, ProgramID: task.program, CreatedAt: now.Add(-task.age), Existing: task.existing})
+			}
+			var got strings.Builder
+			for {
+				task, ok := tq.GetTask()
+				if !ok {
+					break
+				}
+				got.WriteString(task.TaskID)
+			}
+			if got.String() != tt.want {
+				t.Errorf("出队顺序 = %s, 期望 %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+// TestCostEstimatorObserve 测试实际耗时按指数移动平均修正预估，没有初始值的程序直接取第一次观测值
+func TestCostEstimatorObserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  string
+		observed []time.Duration
+		want     time.Duration
+	}{
+		{"未观测时取初始值", "p", nil, 10 * time.Second},
+		{"未知程序取默认值", "unknown", nil, 60 * time.Second},
+		{"一次观测", "p", []time.Duration{20 * time.Second}, 13 * time.Second},
+		{"两次观测", "p", []time.Duration{20 * time.Second, 20 * time.Second}, 15100 * time.Millisecond},
+		{"未知程序第一次观测", "unknown", []time.Duration{4 * time.Second}, 4 * time.Second},
+		{"未知程序第二次观测", "unknown", []time.Duration{4 * time.Second, 14 * time.Second}, 7 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewCostEstimator(map[string]time.Duration{"p": 10 * time.Second}, 60*time.Second)
+			for _, d := range tt.observed {
+				e.Observe(tt.program, d)
+			}
+			if got := e.Estimate(tt.program); got != tt.want {
+				t.Errorf("预估 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestObserveProveTime 测试 worker 记录的耗时影响之后的出队顺序
+func TestObserveProveTime(t *testing.T) {
+	costs := NewCostEstimator(map[string]time.Duration{"a": 10 * time.Second, "b": 20 * time.Second}, time.Minute)
+	tq := NewTaskQueue(10, 10)
+	tq.SetCostEstimator(costs)
+	tq.SetScorer(NewScorer(PriorityWeights{Cost: 1}, costs))
+	// b 实际很快: 20s -> 0.7*20 + 0.3*1 = 14.3s -> 10.31s -> 7.517s
+	for i := 0; i < 3; i++ {
+		tq.ObserveProveTime(&Task{ProgramID: "b"}, time.Second)
+	}
+	tq.AddTask(&Task{TaskID: "a1", ProgramID: "a"})
+	tq.AddTask(&Task{TaskID: "b1", ProgramID: "b"})
+	if task, _ := tq.GetTask(); task.TaskID != "b1" {
+		t.Errorf("第一个出队 %s, 期望 b1（预估 %v）", task.TaskID, costs.Estimate("b"))
+	}
+}
@@ -0,0 +1,211 @@
+package types
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRetryQueueFull 重试队列已满，调用方放弃该证明（不阻塞）
+var ErrRetryQueueFull = errors.New("重试队列已满")
+
+// ErrRetryExhausted 该错误类型的重试次数已用完
+var ErrRetryExhausted = errors.New("重试次数已用完")
+
+// ErrRetryShutdown 纯内存队列关闭时证明仍在等待重试
+var ErrRetryShutdown = errors.New("程序关闭时仍在等待重试")
+
+// RetryPolicy 提交重试策略：第 n 次重试前等待 BaseDelay×Multiplier^(n-1)，不超过 MaxDelay，
+// 再加减 Jitter 比例的随机抖动，避免编排服务恢复时所有证明同时重新提交
+type RetryPolicy struct {
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	Multiplier    float64
+	Jitter        float64        // 0-1
+	MaxAttempts   int            // 默认最多重试次数
+	ClassAttempts map[string]int // 按错误类型（RetryProof.Class）覆盖最多重试次数，0 表示不重试
+}
+
+// Delay 第 attempt 次重试前的等待时间
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	d := float64(p.BaseDelay) * math.Pow(math.Max(p.Multiplier, 1), float64(attempt-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(d)
+}
+
+// Attempts 错误类型的最多重试次数
+func (p RetryPolicy) Attempts(class string) int {
+	if n, ok := p.ClassAttempts[class]; ok {
+		return n
+	}
+	return p.MaxAttempts
+}
+
+// retryHeap 等待重试的证明，按下次提交时间排列
+type retryHeap []*RetryProof
+
+func (h retryHeap) Len() int            { return len(h) }
+func (h retryHeap) Less(i, j int) bool  { return h[i].NextAttempt.Before(h[j].NextAttempt) }
+func (h retryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *retryHeap) Push(x interface{}) { *h = append(*h, x.(*RetryProof)) }
+func (h *retryHeap) Pop() interface{} {
+	old := *h
+	rp := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return rp
+}
+
+// SetDeadLetter 设置放弃重试的证明的去处（如写入死信目录），见 GiveUp
+func (tq *TaskQueue) SetDeadLetter(deadLetter func(rp *RetryProof, reason error)) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.deadLetter = deadLetter
+}
+
+// GiveUp 放弃重试（AddRetry 返回错误时），证明交给死信处理后结束任务；调用方随后可以清理证明数据
+func (tq *TaskQueue) GiveUp(rp *RetryProof, reason error) {
+	tq.mu.RLock()
+	deadLetter := tq.deadLetter
+	tq.mu.RUnlock()
+	if deadLetter != nil {
+		deadLetter(rp, reason)
+	}
+	tq.Done(rp.Task)
+}
+
+// SetRetryPolicy 设置提交重试的退避和次数上限
+func (tq *TaskQueue) SetRetryPolicy(policy RetryPolicy) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.retryPolicy = policy
+}
+
+// AddRetry 安排提交失败的证明重试（不阻塞）：RetryCount 加1，按退避策略计算下次提交时间，
+// 不早于 minDelay（如服务端的 Retry-After）；磁盘队列同时持久化证明。
+// 该错误类型（rp.Class）的重试次数已用完返回 ErrRetryExhausted，队列已满返回 ErrRetryQueueFull，
+// 两种情况证明都不入队，由调用方结束任务
+func (tq *TaskQueue) AddRetry(rp *RetryProof, minDelay time.Duration) error {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	if rp.RetryCount >= tq.retryPolicy.Attempts(rp.Class) {
+		atomic.AddInt64(&tq.stats.retryExhausted, 1)
+		return ErrRetryExhausted
+	}
+	if len(tq.retries)+tq.retryAdding >= tq.retryCapacity {
+		atomic.AddInt64(&tq.stats.retryOverflow, 1)
+		return ErrRetryQueueFull
+	}
+	rp.RetryCount++
+	delay := tq.retryPolicy.Delay(rp.RetryCount)
+	if delay < minDelay {
+		delay = minDelay
+	}
+	now := time.Now()
+	rp.NextAttempt = now.Add(delay)
+	tq.dedup.set(rp.Task.TaskID, taskProved, now)
+	// 写日志时不持有队列锁（同 AddTask），写完才进入重试队列
+	if tq.wal != nil {
+		tq.retryAdding++
+		tq.mu.Unlock()
+		tq.wal.Retry(rp)
+		tq.mu.Lock()
+		tq.retryAdding--
+	}
+	tq.pushRetry(rp)
+	return nil
+}
+
+// pushRetry 加入等待重试的证明并通知 NextRetry 重新计算等待时间（调用方持锁）
+func (tq *TaskQueue) pushRetry(rp *RetryProof) {
+	heap.Push(&tq.retries, rp)
+	tq.signalRetry()
+}
+
+// signalRetry 唤醒一个 NextRetry 重新检查（不阻塞）
+func (tq *TaskQueue) signalRetry() {
+	select {
+	case tq.retryWake <- struct{}{}:
+	default:
+	}
+}
+
+// NextRetry 阻塞直到有证明到达下次提交时间或 ctx 取消（返回 ctx.Err()）
+func (tq *TaskQueue) NextRetry(ctx context.Context) (*RetryProof, error) {
+	for {
+		tq.mu.Lock()
+		wait := time.Duration(-1)
+		if len(tq.retries) > 0 {
+			wait = time.Until(tq.retries[0].NextAttempt)
+			if wait <= 0 {
+				rp := heap.Pop(&tq.retries).(*RetryProof)
+				if len(tq.retries) > 0 {
+					tq.signalRetry() // 可能有其他 NextRetry 在等待
+				}
+				tq.mu.Unlock()
+				return rp, nil
+			}
+		}
+		tq.mu.Unlock()
+
+		var timer *time.Timer
+		var due <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			due = timer.C
+		}
+		select {
+		case <-tq.retryWake:
+		case <-due:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// TryGetRetry 获取已到提交时间的重试证明（非阻塞）
+func (tq *TaskQueue) TryGetRetry() (*RetryProof, bool) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	if len(tq.retries) == 0 || time.Now().Before(tq.retries[0].NextAttempt) {
+		return nil, false
+	}
+	return heap.Pop(&tq.retries).(*RetryProof), true
+}
+
+// DrainRetries 取出全部等待重试的证明（不论是否到达提交时间），按下次提交时间排列；
+// 纯内存队列关闭时由调用方交给 GiveUp，避免证明随进程退出丢失
+func (tq *TaskQueue) DrainRetries() []*RetryProof {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	drained := make([]*RetryProof, 0, len(tq.retries))
+	for len(tq.retries) > 0 {
+		drained = append(drained, heap.Pop(&tq.retries).(*RetryProof))
+	}
+	return drained
+}
+
+// RetryStats 等待重试的证明数，因队列已满和重试次数用完而放弃的证明数
+func (tq *TaskQueue) RetryStats() (pending int, overflow, exhausted int64) {
+	tq.mu.RLock()
+	pending = len(tq.retries)
+	tq.mu.RUnlock()
+	return pending, atomic.LoadInt64(&tq.stats.retryOverflow), atomic.LoadInt64(&tq.stats.retryExhausted)
+}
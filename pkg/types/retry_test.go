@@ -0,0 +1,197 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestRetryDelay 测试退避时间按倍数增长并受 max_delay 限制
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"第1次为基础延迟", RetryPolicy{BaseDelay: time.Second, Multiplier: 2}, 1, time.Second},
+		{"第2次翻倍", RetryPolicy{BaseDelay: time.Second, Multiplier: 2}, 2, 2 * time.Second},
+		{"第4次", RetryPolicy{BaseDelay: time.Second, Multiplier: 2}, 4, 8 * time.Second},
+		{"倍数1.5", RetryPolicy{BaseDelay: 4 * time.Second, Multiplier: 1.5}, 3, 9 * time.Second},
+		{"达到上限", RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2}, 4, 5 * time.Second},
+		{"远超上限", RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2}, 100, 5 * time.Second},
+		{"未达上限", RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second, Multiplier: 2}, 3, 4 * time.Second},
+		{"倍数小于1按1处理", RetryPolicy{BaseDelay: 3 * time.Second, Multiplier: 0.5}, 5, 3 * time.Second},
+		{"基础延迟为0", RetryPolicy{Multiplier: 2, Jitter: 0.5}, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Delay(tt.attempt); got != tt.want {
+				t.Errorf("Delay(%d) = %v, 期望 %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRetryDelayJitter 测试随机抖动不超出 ±jitter 比例，且达到上限后仍然抖动
+func TestRetryDelayJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		attempt  int
+		min, max time.Duration
+	}{
+		{"基础延迟", RetryPolicy{BaseDelay: 10 * time.Second, Multiplier: 2, Jitter: 0.2}, 1, 8 * time.Second, 12 * time.Second},
+		{"增长后", RetryPolicy{BaseDelay: 10 * time.Second, Multiplier: 2, Jitter: 0.5}, 3, 20 * time.Second, 60 * time.Second},
+		{"达到上限", RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 2, Jitter: 0.1}, 20, 9 * time.Second, 11 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := make(map[time.Duration]bool)
+			for i := 0; i < 1000; i++ {
+				d := tt.policy.Delay(tt.attempt)
+				if d < tt.min || d > tt.max {
+					t.Fatalf("Delay(%d) = %v, 超出 [%v, %v]", tt.attempt, d, tt.min, tt.max)
+				}
+				seen[d] = true
+			}
+			if len(seen) < 2 {
+				t.Errorf("抖动未生效")
+			}
+		})
+	}
+}
+
+// TestRetryAttempts 测试按错误类型覆盖最多重试次数
+func TestRetryAttempts(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, ClassAttempts: map[string]int{"rate_limit": 10, "unauthorized": 0}}
+	tests := []struct {
+		class string
+		want  int
+	}{
+		{"rate_limit", 10},
+		{"unauthorized", 0},
+		{"server", 3},
+		{"", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			if got := policy.Attempts(tt.class); got != tt.want {
+				t.Errorf("Attempts(%q) = %d, 期望 %d", tt.class, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAddRetry 测试重试入队、次数用完和队列已满
+func TestAddRetry(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Minute, Multiplier: 2, MaxAttempts: 2, ClassAttempts: map[string]int{"unauthorized": 0}}
+	tests := []struct {
+		name      string
+		capacity  int
+		pending   int // 预先入队的重试数
+		rp        *RetryProof
+		minDelay  time.Duration
+		wantErr   error
+		wantDelay time.Duration
+		wantCount int
+		overflow  int64
+		exhausted int64
+	}{
+		{"第1次重试", 4, 0, &RetryProof{Task: testTask("t1")}, 0, nil, time.Minute, 1, 0, 0},
+		{"第2次重试翻倍", 4, 0, &RetryProof{Task: testTask("t1"), RetryCount: 1}, 0, nil, 2 * time.Minute, 2, 0, 0},
+		{"不早于 Retry-After", 4, 0, &RetryProof{Task: testTask("t1")}, time.Hour, nil, time.Hour, 1, 0, 0},
+		{"次数用完", 4, 0, &RetryProof{Task: testTask("t1"), RetryCount: 2}, 0, ErrRetryExhausted, 0, 2, 0, 1},
+		{"class_attempts 为0不重试", 4, 0, &RetryProof{Task: testTask("t1"), Class: "unauthorized"}, 0, ErrRetryExhausted, 0, 0, 0, 1},
+		{"队列已满", 2, 2, &RetryProof{Task: testTask("t1")}, 0, ErrRetryQueueFull, 0, 0, 1, 0},
+		{"次数用完优先于队列已满", 1, 1, &RetryProof{Task: testTask("t1"), RetryCount: 2}, 0, ErrRetryExhausted, 0, 2, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tq := NewTaskQueue(10, tt.capacity)
+			tq.SetRetryPolicy(policy)
+			for i := 0; i < tt.pending; i++ {
+				if err := tq.AddRetry(&RetryProof{Task: testTask(string(rune('a' + i)))}, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := time.Now()
+			err := tq.AddRetry(tt.rp, tt.minDelay)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AddRetry 错误 = %v, 期望 %v", err, tt.wantErr)
+			}
+			if tt.rp.RetryCount != tt.wantCount {
+				t.Errorf("RetryCount = %d, 期望 %d", tt.rp.RetryCount, tt.wantCount)
+			}
+			pending, overflow, exhausted := tq.RetryStats()
+			if overflow != tt.overflow || exhausted != tt.exhausted {
+				t.Errorf("放弃统计 = 队列已满 %d, 次数用完 %d", overflow, exhausted)
+			}
+			if err != nil {
+				if pending != tt.pending {
+					t.Errorf("失败的重试不应入队: %d 个等待重试", pending)
+				}
+				return
+			}
+			if pending != tt.pending+1 {
+				t.Errorf("等待重试 = %d, 期望 %d", pending, tt.pending+1)
+			}
+			if delay := tt.rp.NextAttempt.Sub(before); delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+				t.Errorf("下次提交在 %v 后, 期望 %v", delay, tt.wantDelay)
+			}
+		})
+	}
+}
+
+// TestNextRetry 测试按下次提交时间先后取出重试，未到时间时阻塞
+func TestNextRetry(t *testing.T) {
+	tq := NewTaskQueue(10, 10)
+	tq.SetRetryPolicy(RetryPolicy{BaseDelay: 30 * time.Millisecond, Multiplier: 1, MaxAttempts: 3})
+	tq.AddRetry(&RetryProof{Task: testTask("late")}, time.Hour)
+	tq.AddRetry(&RetryProof{Task: testTask("soon")}, 0)
+	if _, ok := tq.TryGetRetry(); ok {
+		t.Fatal("未到提交时间不应取出")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	rp, err := tq.NextRetry(ctx)
+	if err != nil || rp.Task.TaskID != "soon" {
+		t.Fatalf("NextRetry = %v, %v, 期望 soon", rp, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := tq.NextRetry(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("NextRetry 错误 = %v, 期望超时", err)
+	}
+}
+
+// TestDrainRetries 测试关闭时取出全部等待重试的证明，交给死信处理后任务结束
+func TestDrainRetries(t *testing.T) {
+	tq := NewTaskQueue(10, 10)
+	tq.SetRetryPolicy(RetryPolicy{BaseDelay: time.Minute, Multiplier: 1, MaxAttempts: 3})
+	tq.AddRetry(&RetryProof{Task: testTask("late")}, time.Hour)
+	tq.AddRetry(&RetryProof{Task: testTask("soon")}, 0)
+
+	var dead []string
+	tq.SetDeadLetter(func(rp *RetryProof, reason error) {
+		if !errors.Is(reason, ErrRetryShutdown) {
+			t.Errorf("放弃原因 = %v", reason)
+		}
+		dead = append(dead, rp.Task.TaskID)
+	})
+	for _, rp := range tq.DrainRetries() {
+		tq.GiveUp(rp, ErrRetryShutdown)
+	}
+	if len(dead) != 2 || dead[0] != "soon" || dead[1] != "late" {
+		t.Errorf("死信 = %v, 期望 [soon late]", dead)
+	}
+	if pending, _, _ := tq.RetryStats(); pending != 0 {
+		t.Errorf("等待重试 = %d, 期望 0", pending)
+	}
+	if drained := tq.DrainRetries(); len(drained) != 0 {
+		t.Errorf("再次取出 %d 个", len(drained))
+	}
+}
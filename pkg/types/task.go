@@ -1,6 +1,9 @@
 package types
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -12,14 +15,19 @@ type Task struct {
 	ProgramID    string
 	PublicInputs []byte
 	NodeID       string
-	CreatedAt    time.Time
+	CreatedAt    time.Time // 服务端创建时间，服务端未返回时为获取时间
+	Existing     bool      // 来自 GetExistingTasks（已分配给本节点），否则为 GetNewTask 新获取的任务
 }
 
 // RetryProof 提交重试结构体
 type RetryProof struct {
-	Task       *Task
-	Proof      []byte
-	RetryCount int
+	Task        *Task
+	Proof       []byte
+	RetryCount  int       // 已安排的重试次数
+	Class       string    // 最近一次提交失败的错误类型，用于按类型限制重试次数
+	LastError   string    // 最近一次提交失败的错误
+	FailedAt    time.Time // 首次提交失败时间
+	NextAttempt time.Time // 下次提交时间
 }
 
 // LightRetryProof 轻量级重试结构体 - 只存储必要信息
@@ -31,68 +39,389 @@ type LightRetryProof struct {
 	RetryCount int
 }
 
+// ErrQueueFull 任务队列已满
+var ErrQueueFull = errors.New("任务队列已满")
+
+// ErrDuplicateTask 任务已在队列中、正在处理或最近已处理过
+var ErrDuplicateTask = errors.New("重复任务")
+
 // TaskQueue 任务队列结构体
+//
+// 默认先进先出；设置打分函数（SetScorer）后按优先级出队。
+// 任务ID在排队、计算、等待提交期间以及结束后的一段时间内（见 SetDedup）去重，重复入队被拒绝并计数。
 type TaskQueue struct {
-	tasks chan *Task
-	mu    sync.RWMutex
-	stats struct {
-		queued    int64
-		processed int64
-		failed    int64
+	tasks    []*Task
+	depths   map[string]int // 节点ID => 排队中的任务数
+	capacity int
+	scorer   TaskScorer // nil 表示先进先出
+	fair     *fairQueue // nil 表示不区分节点
+	costs    *CostEstimator
+	expiry   ExpiryPolicy
+	mu       sync.RWMutex
+	dedup    *dedupCache
+	stats    struct {
+		queued         int64
+		processed      int64
+		failed         int64
+		duplicates     int64
+		expired        int64
+		retryOverflow  int64
+		retryExhausted int64
 	}
-	// 新增重试队列
-	retryQueue chan *RetryProof
+	adding  int             // 正在写日志、尚未入队的任务数，计入队列容量
+	waiters []chan struct{} // 阻塞在 Next 上的等待者，按等待先后排列
+	wakeups int             // 已唤醒但尚未取走任务的等待者数量
+	// 提交重试：按下次提交时间排列的延迟队列，满了不阻塞（见 AddRetry）
+	retries       retryHeap
+	retryCapacity int
+	retryAdding   int // 正在写日志、尚未进入重试队列的证明数，计入重试队列容量
+	retryPolicy   RetryPolicy
+	retryWake     chan struct{}
+	deadLetter    func(rp *RetryProof, reason error)
+	onDone        func(task *Task)
+	wal           *WAL // 磁盘队列的预写日志，nil 表示纯内存队列
 }
 
-// NewTaskQueue 创建新的任务队列
+// NewTaskQueue 创建新的任务队列（纯内存，重启后丢失）
 func NewTaskQueue(capacity int, retryCapacity int) *TaskQueue {
 	return &TaskQueue{
-		tasks:      make(chan *Task, capacity),
-		retryQueue: make(chan *RetryProof, retryCapacity),
+		tasks:         make([]*Task, 0, capacity),
+		depths:        make(map[string]int),
+		capacity:      capacity,
+		dedup:         newDedupCache(DEFAULT_DEDUP_TTL, DEFAULT_DEDUP_SIZE),
+		retryCapacity: retryCapacity,
+		retryWake:     make(chan struct{}, 1),
+	}
+}
+
+// SetDedup 设置去重缓存：已结束的任务保留 ttl 时间，最多保留 size 个
+func (tq *TaskQueue) SetDedup(ttl time.Duration, size int) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.dedup.ttl = ttl
+	tq.dedup.size = size
+}
+
+// SetScorer 切换为优先级调度，出队时取分数最高的任务，分数相同时先入队的优先；nil 恢复先进先出
+func (tq *TaskQueue) SetScorer(scorer TaskScorer) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.scorer = scorer
+}
+
+// SetCostEstimator 设置证明耗时预估，worker 通过 ObserveProveTime 记录实际耗时
+func (tq *TaskQueue) SetCostEstimator(costs *CostEstimator) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.costs = costs
+}
+
+// ObserveProveTime 记录任务的实际证明耗时，用于修正同一程序的预估耗时
+func (tq *TaskQueue) ObserveProveTime(task *Task, d time.Duration) {
+	tq.mu.RLock()
+	costs := tq.costs
+	tq.mu.RUnlock()
+	if costs != nil {
+		costs.Observe(task.ProgramID, d)
+	}
+}
+
+// OpenTaskQueue 创建磁盘任务队列：打开预写日志，恢复排队中、正在计算和等待重新提交的任务；
+// 恢复的任务超过容量时队列按恢复数量扩容，保证不丢失
+func OpenTaskQueue(capacity int, retryCapacity int, opts WALOptions) (*TaskQueue, WALRecovery, error) {
+	wal, err := OpenWAL(opts)
+	if err != nil {
+		return nil, WALRecovery{}, err
+	}
+	tasks, retries, recovered := wal.Recover()
+	if len(tasks) > capacity {
+		capacity = len(tasks)
+	}
+	if len(retries) > retryCapacity {
+		retryCapacity = len(retries)
 	}
+	tq := NewTaskQueue(capacity, retryCapacity)
+	tq.wal = wal
+	now := time.Now()
+	for _, task := range tasks {
+		tq.push(task)
+		tq.dedup.set(task.TaskID, taskQueued, now)
+	}
+	atomic.AddInt64(&tq.stats.queued, int64(len(tasks)))
+	// 恢复的证明立即重新提交
+	for _, rp := range retries {
+		tq.pushRetry(rp)
+		tq.dedup.set(rp.Task.TaskID, taskProved, now)
+	}
+	return tq, recovered, nil
 }
 
-// AddTask 添加任务到队列
-func (tq *TaskQueue) AddTask(task *Task) bool {
-	select {
-	case tq.tasks <- task:
-		atomic.AddInt64(&tq.stats.queued, 1)
-		return true
-	default:
-		return false // 队列已满
+// Durable 是否为磁盘队列
+func (tq *TaskQueue) Durable() bool {
+	return tq.wal != nil
+}
+
+// AddTask 添加任务到队列，队列已满返回 ErrQueueFull，任务重复返回 ErrDuplicateTask
+func (tq *TaskQueue) AddTask(task *Task) error {
+	tq.mu.Lock()
+	now := time.Now()
+	if tq.dedup.seen(task.TaskID, now) {
+		tq.mu.Unlock()
+		atomic.AddInt64(&tq.stats.duplicates, 1)
+		return ErrDuplicateTask
+	}
+	if len(tq.tasks)+tq.adding >= tq.capacity {
+		tq.mu.Unlock()
+		return ErrQueueFull
 	}
+	tq.dedup.set(task.TaskID, taskQueued, now)
+	// 先写日志再入队，避免worker取出任务时日志中还没有该任务；写日志（always 策略下含刷盘）时
+	// 不持有队列锁，不阻塞 Next、Len 等调用，占用的容量由 adding 计入
+	if tq.wal != nil {
+		tq.adding++
+		tq.mu.Unlock()
+		tq.wal.Add(task)
+		tq.mu.Lock()
+		tq.adding--
+	}
+	tq.push(task)
+	tq.wakeOne()
+	tq.mu.Unlock()
+	atomic.AddInt64(&tq.stats.queued, 1)
+	return nil
 }
 
-// GetTask 从队列获取任务
+// GetTask 从队列获取任务（非阻塞），磁盘队列记录任务开始计算；已唤醒的 Next 等待者优先
 func (tq *TaskQueue) GetTask() (*Task, bool) {
-	select {
-	case task := <-tq.tasks:
-		return task, true
-	default:
+	tq.mu.Lock()
+	if len(tq.tasks) <= tq.wakeups {
+		tq.mu.Unlock()
 		return nil, false // 队列为空
 	}
+	task, dropped, ok := tq.take(time.Now())
+	tq.mu.Unlock()
+	tq.drop(dropped)
+	if !ok {
+		return nil, false // 排队的任务都已过期
+	}
+	tq.started(task)
+	return task, true
+}
+
+// Next 阻塞直到取到任务或 ctx 取消（返回 ctx.Err()）；多个worker同时等待时按等待先后唤醒，
+// 先等待的先拿到任务，新来的调用不会插队
+func (tq *TaskQueue) Next(ctx context.Context) (*Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var dropped []droppedTask
+	tq.mu.Lock()
+	if len(tq.waiters) == 0 && len(tq.tasks) > tq.wakeups {
+		task, d, ok := tq.take(time.Now())
+		if ok {
+			tq.mu.Unlock()
+			tq.drop(d)
+			tq.started(task)
+			return task, nil
+		}
+		dropped = d
+	}
+
+	front := false
+	for {
+		wake := make(chan struct{}, 1)
+		if front {
+			// 被唤醒后发现任务都已过期，排回队首，不失去等待顺序
+			tq.waiters = append([]chan struct{}{wake}, tq.waiters...)
+		} else {
+			tq.waiters = append(tq.waiters, wake)
+		}
+		tq.wakeOne()
+		tq.mu.Unlock()
+		tq.drop(dropped)
+		dropped = nil
+
+		select {
+		case <-wake:
+			tq.mu.Lock()
+			tq.wakeups--
+			task, d, ok := tq.take(time.Now())
+			if ok {
+				tq.mu.Unlock()
+				tq.drop(d)
+				tq.started(task)
+				return task, nil
+			}
+			dropped = d
+			front = true
+		case <-ctx.Done():
+			tq.mu.Lock()
+			if !tq.removeWaiter(wake) {
+				// 取消的同时已被唤醒，把唤醒让给下一个等待者
+				tq.wakeups--
+				tq.wakeOne()
+			}
+			tq.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
 }
 
-// AddRetry 添加重试任务
-func (tq *TaskQueue) AddRetry(rp *RetryProof) {
-	tq.retryQueue <- rp
+// take 取出下一个能在过期前完成的任务（调用方持锁）；已过期或预估无法按时完成的任务移出队列，
+// 由调用方释放锁后交给 drop 处理
+func (tq *TaskQueue) take(now time.Time) (*Task, []droppedTask, bool) {
+	var dropped []droppedTask
+	for len(tq.tasks) > 0 {
+		i := tq.next(now)
+		task := tq.tasks[i]
+		err := tq.checkExpiry(task, now)
+		if err == nil {
+			tq.charge(task) // 过期丢弃的任务不占用节点额度
+		}
+		tq.remove(i)
+		if err != nil {
+			dropped = append(dropped, droppedTask{task: task, reason: err})
+			continue
+		}
+		tq.dedup.set(task.TaskID, taskProving, now)
+		return task, dropped, true
+	}
+	return nil, dropped, false
 }
 
-// GetRetry 获取重试任务（阻塞）
-func (tq *TaskQueue) GetRetry() *RetryProof {
-	return <-tq.retryQueue
+// started 磁盘队列记录任务开始计算
+func (tq *TaskQueue) started(task *Task) {
+	if tq.wal != nil {
+		tq.wal.Start(task.TaskID)
+	}
 }
 
-// TryGetRetry 获取重试任务（非阻塞）
-func (tq *TaskQueue) TryGetRetry() (*RetryProof, bool) {
-	select {
-	case rp := <-tq.retryQueue:
-		return rp, true
-	default:
-		return nil, false
+// wakeOne 有未被认领的任务时唤醒等待最久的 Next 调用（调用方持锁）
+func (tq *TaskQueue) wakeOne() {
+	for len(tq.waiters) > 0 && len(tq.tasks) > tq.wakeups {
+		wake := tq.waiters[0]
+		tq.waiters = tq.waiters[1:]
+		tq.wakeups++
+		wake <- struct{}{}
 	}
 }
 
+// removeWaiter 从等待列表中移除，已被唤醒（不在列表中）时返回 false（调用方持锁）
+func (tq *TaskQueue) removeWaiter(wake chan struct{}) bool {
+	for i, w := range tq.waiters {
+		if w == wake {
+			tq.waiters = append(tq.waiters[:i], tq.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// next 下一个出队任务的下标（调用方持锁，队列非空）：先进先出时为队首，
+// 优先级调度时重新计算全部排队任务的分数取最高（队列容量有限，线性扫描即可）；
+// 按节点公平出队时先选节点（见 SetFairness）
+func (tq *TaskQueue) next(now time.Time) int {
+	if tq.fair != nil {
+		return tq.nextFair(now)
+	}
+	if tq.scorer == nil {
+		return 0
+	}
+	best, bestScore := 0, tq.scorer(tq.tasks[0], now)
+	for i := 1; i < len(tq.tasks); i++ {
+		if score := tq.scorer(tq.tasks[i], now); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// Len 排队中的任务数
+func (tq *TaskQueue) Len() int {
+	tq.mu.RLock()
+	defer tq.mu.RUnlock()
+	return len(tq.tasks)
+}
+
+// Capacity 队列容量（磁盘队列恢复的任务超过配置容量时为恢复的任务数）
+func (tq *TaskQueue) Capacity() int {
+	tq.mu.RLock()
+	defer tq.mu.RUnlock()
+	return tq.capacity
+}
+
+// Idle 阻塞在 Next 上等待任务的worker数量
+func (tq *TaskQueue) Idle() int {
+	tq.mu.RLock()
+	defer tq.mu.RUnlock()
+	return len(tq.waiters)
+}
+
+// SetOnDone 设置任务结束（见 Done、Forget）时的回调，用于清理按任务记录的数据（如遥测）
+func (tq *TaskQueue) SetOnDone(onDone func(task *Task)) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.onDone = onDone
+}
+
+// Done 任务结束（提交成功、丢弃或放弃重试），磁盘队列不再恢复该任务；
+// 必须在清理证明数据之前调用
+func (tq *TaskQueue) Done(task *Task) {
+	tq.setState(task, taskDone)
+	if tq.wal != nil {
+		tq.wal.Done(task.TaskID)
+	}
+	tq.mu.RLock()
+	onDone := tq.onDone
+	tq.mu.RUnlock()
+	if onDone != nil {
+		onDone(task)
+	}
+}
+
+// Forget 证明计算失败：磁盘队列不再恢复该任务，同时从去重缓存中删除，
+// 服务端再次返回该任务（已分配任务每轮都会返回）时重新入队计算，而不是在去重期内一直被拒绝
+func (tq *TaskQueue) Forget(task *Task) {
+	tq.mu.Lock()
+	tq.dedup.forget(task.TaskID)
+	onDone := tq.onDone
+	tq.mu.Unlock()
+	if tq.wal != nil {
+		tq.wal.Done(task.TaskID)
+	}
+	if onDone != nil {
+		onDone(task)
+	}
+}
+
+// setState 更新任务的去重状态
+func (tq *TaskQueue) setState(task *Task, state taskState) {
+	tq.mu.Lock()
+	defer tq.mu.Unlock()
+	tq.dedup.set(task.TaskID, state, time.Now())
+}
+
+// Suspend 程序关闭时保存提交被中断的证明，下次启动后重新提交；纯内存队列返回 false，
+// 返回 true 时证明数据已交给日志，调用方不要清理
+func (tq *TaskQueue) Suspend(rp *RetryProof) bool {
+	if tq.wal == nil {
+		return false
+	}
+	tq.wal.Retry(rp)
+	return true
+}
+
+// Close 关闭任务队列，磁盘队列刷盘后关闭日志
+func (tq *TaskQueue) Close() error {
+	if tq.wal == nil {
+		return nil
+	}
+	if err := tq.wal.Close(); err != nil {
+		return fmt.Errorf("关闭任务队列日志失败: %v", err)
+	}
+	return nil
+}
+
 // GetStats 获取队列统计信息
 func (tq *TaskQueue) GetStats() (int64, int64, int64) {
 	return atomic.LoadInt64(&tq.stats.queued),
@@ -100,6 +429,11 @@ func (tq *TaskQueue) GetStats() (int64, int64, int64) {
 		atomic.LoadInt64(&tq.stats.failed)
 }
 
+// Duplicates 被拒绝的重复任务数
+func (tq *TaskQueue) Duplicates() int64 {
+	return atomic.LoadInt64(&tq.stats.duplicates)
+}
+
 // MarkProcessed 标记任务处理完成
 func (tq *TaskQueue) MarkProcessed() {
 	atomic.AddInt64(&tq.stats.processed, 1)
@@ -114,23 +448,30 @@ func (tq *TaskQueue) MarkFailed() {
 type TaskFetchState struct {
 	lastFetchTime    time.Time
 	lastQueueLogTime time.Time
+	fetchInterval    time.Duration
 	queueLogInterval time.Duration
 	Consecutive404s  int
 }
 
-// NewTaskFetchState 创建新的任务获取状态
-func NewTaskFetchState() *TaskFetchState {
+// NewTaskFetchState 创建新的任务获取状态，fetchInterval 为固定获取间隔，queueLogInterval 为队列日志间隔
+func NewTaskFetchState(fetchInterval, queueLogInterval time.Duration) *TaskFetchState {
 	return &TaskFetchState{
-		lastFetchTime:    time.Now().Add(-180*time.Second - time.Second), // 允许立即首次获取
+		lastFetchTime:    time.Now().Add(-fetchInterval - time.Second), // 允许立即首次获取
 		lastQueueLogTime: time.Now(),
-		queueLogInterval: 30 * time.Second,
+		fetchInterval:    fetchInterval,
+		queueLogInterval: queueLogInterval,
 		Consecutive404s:  0,
 	}
 }
 
 // ShouldFetch 检查是否应该获取任务
 func (s *TaskFetchState) ShouldFetch() bool {
-	return time.Since(s.lastFetchTime) >= 180*time.Second // 固定间隔检查
+	return time.Since(s.lastFetchTime) >= s.fetchInterval // 固定间隔检查
+}
+
+// SetFetchInterval 更新获取间隔（节点级覆盖热更新后生效）
+func (s *TaskFetchState) SetFetchInterval(fetchInterval time.Duration) {
+	s.fetchInterval = fetchInterval
 }
 
 // SetLastFetchTime 设置获取任务的时间
@@ -0,0 +1,212 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitIdle 等待指定数量的 Next 调用进入等待
+func waitIdle(t *testing.T, tq *TaskQueue, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for tq.Idle() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待者数量 = %d, 期望 %d", tq.Idle(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type nextResult struct {
+	waiter int
+	task   *Task
+	err    error
+}
+
+// TestNextFIFOWake 测试多个 Next 等待时按等待先后拿到任务
+func TestNextFIFOWake(t *testing.T) {
+	const waiters = 5
+	tq := NewTaskQueue(10, 10)
+	results := make(chan nextResult, waiters)
+	for i := 0; i < waiters; i++ {
+		go func(i int) {
+			task, err := tq.Next(context.Background())
+			results <- nextResult{waiter: i, task: task, err: err}
+		}(i)
+		waitIdle(t, tq, i+1)
+	}
+
+	for i := 0; i < waiters; i++ {
+		taskID := fmt.Sprintf("t%d", i)
+		if err := tq.AddTask(&Task{TaskID: taskID}); err != nil {
+			t.Fatal(err)
+		}
+		r := <-results
+		if r.err != nil || r.waiter != i || r.task.TaskID != taskID {
+			t.Errorf("第 %d 个任务: 等待者 %d 拿到 %v (%v), 期望等待者 %d", i, r.waiter, r.task, r.err, i)
+		}
+	}
+
+	// 没有等待者时直接取走
+	tq.AddTask(&Task{TaskID: "direct"})
+	task, err := tq.Next(context.Background())
+	if err != nil || task.TaskID != "direct" {
+		t.Errorf("Next = %v, %v", task, err)
+	}
+}
+
+// TestNextCancelHandoff 测试等待者被唤醒的同时 ctx 取消时，把唤醒让给下一个等待者，任务不会滞留在队列中
+func TestNextCancelHandoff(t *testing.T) {
+	tq := NewTaskQueue(10, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan nextResult, 1)
+	go func() {
+		task, err := tq.Next(ctx)
+		first <- nextResult{task: task, err: err}
+	}()
+	waitIdle(t, tq, 1)
+	second := make(chan nextResult, 1)
+	go func() {
+		task, err := tq.Next(context.Background())
+		second <- nextResult{task: task, err: err}
+	}()
+	waitIdle(t, tq, 2)
+
+	// 持锁时取消，第一个等待者选中 ctx.Done 后阻塞在锁上；此时入队并唤醒它
+	tq.mu.Lock()
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	tq.push(&Task{TaskID: "t1"})
+	tq.wakeOne()
+	tq.mu.Unlock()
+
+	if r := <-first; !errors.Is(r.err, context.Canceled) {
+		t.Fatalf("取消的等待者返回 %v, %v", r.task, r.err)
+	}
+	select {
+	case r := <-second:
+		if r.err != nil || r.task.TaskID != "t1" {
+			t.Errorf("第二个等待者拿到 %v, %v", r.task, r.err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("唤醒没有让给第二个等待者")
+	}
+	tq.mu.RLock()
+	defer tq.mu.RUnlock()
+	if tq.wakeups != 0 || len(tq.waiters) != 0 || len(tq.tasks) != 0 {
+		t.Errorf("wakeups = %d, waiters = %d, tasks = %d", tq.wakeups, len(tq.waiters), len(tq.tasks))
+	}
+}
+
+// TestGetTaskNoSteal 测试已唤醒的 Next 等待者认领的任务不会被 GetTask 取走
+func TestGetTaskNoSteal(t *testing.T) {
+	tq := NewTaskQueue(10, 10)
+	for i := 0; i < 100; i++ {
+		result := make(chan nextResult, 1)
+		go func() {
+			task, err := tq.Next(context.Background())
+			result <- nextResult{task: task, err: err}
+		}()
+		waitIdle(t, tq, 1)
+
+		taskID := fmt.Sprintf("t%d", i)
+		tq.AddTask(&Task{TaskID: taskID})
+		if task, ok := tq.GetTask(); ok {
+			t.Fatalf("GetTask 取走了已唤醒等待者的任务 %s", task.TaskID)
+		}
+		if r := <-result; r.err != nil || r.task.TaskID != taskID {
+			t.Fatalf("等待者拿到 %v, %v", r.task, r.err)
+		}
+	}
+
+	// 没有等待者时 GetTask 正常取任务
+	tq.AddTask(&Task{TaskID: "direct"})
+	if task, ok := tq.GetTask(); !ok || task.TaskID != "direct" {
+		t.Errorf("GetTask = %v, %v", task, ok)
+	}
+}
+
+// TestNextConcurrent 测试并发入队、Next、GetTask 和取消时每个任务恰好被取走一次
+func TestNextConcurrent(t *testing.T) {
+	const producers, perProducer, consumers = 4, 50, 8
+	tq := NewTaskQueue(producers*perProducer, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	got := make(map[string]int)
+	var wg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for {
+				var task *Task
+				if c%4 == 0 {
+					var ok bool
+					if task, ok = tq.GetTask(); !ok {
+						if ctx.Err() != nil {
+							return
+						}
+						time.Sleep(time.Millisecond)
+						continue
+					}
+				} else {
+					// 部分等待很快超时，覆盖取消与唤醒并发的情况
+					waitCtx, waitCancel := context.WithTimeout(ctx, time.Duration(c)*time.Millisecond)
+					var err error
+					task, err = tq.Next(waitCtx)
+					waitCancel()
+					if err != nil {
+						if ctx.Err() != nil {
+							return
+						}
+						continue
+					}
+				}
+				mu.Lock()
+				got[task.TaskID]++
+				mu.Unlock()
+			}
+		}(c)
+	}
+
+	var pwg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		pwg.Add(1)
+		go func(p int) {
+			defer pwg.Done()
+			for i := 0; i < perProducer; i++ {
+				if err := tq.AddTask(&Task{TaskID: fmt.Sprintf("p%d-%d", p, i)}); err != nil {
+					t.Error(err)
+				}
+			}
+		}(p)
+	}
+	pwg.Wait()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if n == producers*perProducer {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("只取走了 %d 个任务, 队列中还有 %d 个", n, tq.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+	for id, n := range got {
+		if n != 1 {
+			t.Errorf("任务 %s 被取走 %d 次", id, n)
+		}
+	}
+}
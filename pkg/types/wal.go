@@ -0,0 +1,507 @@
+package types
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// WAL 日志文件名
+const walFileName = "tasks.wal"
+
+// WAL 记录类型
+const (
+	walOpAdd   = "add"   // 任务入队
+	walOpStart = "start" // 任务开始证明计算
+	walOpRetry = "retry" // 证明等待重新提交（含证明数据）
+	walOpDone  = "done"  // 任务结束（提交成功、丢弃或放弃重试）
+)
+
+// walHeaderSize 每条记录的头部: 4字节长度 + 4字节 CRC32
+const walHeaderSize = 8
+
+// WALOptions 磁盘任务队列选项
+type WALOptions struct {
+	Dir            string        // 日志目录
+	SyncInterval   time.Duration // 0: 每条记录写入后刷盘; >0: 后台按间隔刷盘; <0: 不主动刷盘，交给操作系统
+	CompactRecords int           // 日志记录数超过该值且超过一半已失效时压缩，<=0 表示不压缩
+	OnError        func(error)   // 写日志失败时回调（队列继续在内存中工作）
+}
+
+// WALRecovery 启动时从日志恢复的任务数
+type WALRecovery struct {
+	Queued   int // 排队中的任务
+	InFlight int // 崩溃时正在证明计算的任务，重新排队
+	Retries  int // 等待重新提交的证明
+}
+
+// walRecord 日志记录，JSON 编码
+type walRecord struct {
+	Op         string     `json:"op"`
+	TaskID     string     `json:"id"`
+	Task       *Task      `json:"task,omitempty"`
+	Proof      []byte     `json:"proof,omitempty"`
+	RetryCount int        `json:"retry_count,omitempty"`
+	Class      string     `json:"class,omitempty"`      // 最近一次提交失败的错误类型，重启后继续按类型限制重试次数
+	LastError  string     `json:"last_error,omitempty"` // 最近一次提交失败的错误，放弃时写入死信
+	FailedAt   *time.Time `json:"failed_at,omitempty"`  // 首次提交失败时间
+}
+
+// walEntry 日志中仍然有效的任务
+type walEntry struct {
+	seq        int64
+	task       *Task
+	inFlight   bool
+	proof      []byte // 非空表示等待重新提交
+	retryCount int
+	class      string
+	lastError  string
+	failedAt   time.Time
+}
+
+// WAL 任务队列的预写日志（追加写），记录任务从入队到结束的状态变化，重启时据此恢复
+type WAL struct {
+	opts WALOptions
+	path string
+
+	mu             sync.Mutex
+	file           *os.File
+	buf            *bufio.Writer
+	live           map[string]*walEntry
+	seq            int64
+	records        int   // 日志文件中的记录数（含缓冲区中的）
+	good           int64 // 文件中完整记录的结束位置，写入失败时截断到这里
+	pending        int64 // 缓冲区中尚未写入文件的字节数
+	pendingRecords int   // 缓冲区中尚未写入文件的记录数
+	dirty          bool  // 有未刷盘的记录
+	stop           chan struct{}
+	done           chan struct{}
+}
+
+// OpenWAL 打开（不存在时创建）日志目录并回放日志，末尾不完整或校验失败的记录被截断
+func OpenWAL(opts WALOptions) (*WAL, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("任务队列日志目录不能为空")
+	}
+	if err := os.MkdirAll(opts.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建任务队列日志目录失败: %v", err)
+	}
+	w := &WAL{
+		opts: opts,
+		path: filepath.Join(opts.Dir, walFileName),
+		live: make(map[string]*walEntry),
+	}
+	f, err := os.OpenFile(w.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("打开任务队列日志失败: %v", err)
+	}
+	// 同一目录只允许一个进程使用
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("任务队列日志 %s 正被其他进程使用: %v", w.path, err)
+	}
+	valid, err := w.replay(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("截断任务队列日志失败: %v", err)
+	}
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.file = f
+	w.buf = bufio.NewWriter(f)
+	w.good = valid
+
+	if opts.SyncInterval > 0 {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.syncLoop()
+	}
+	return w, nil
+}
+
+// replay 回放日志重建有效任务，返回最后一条完整记录的结束位置
+func (w *WAL) replay(f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+	var offset int64
+	header := make([]byte, walHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return offset, nil // 文件结束或头部不完整
+		}
+		size := binary.LittleEndian.Uint32(header[0:4])
+		sum := binary.LittleEndian.Uint32(header[4:8])
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return offset, nil // 写入中途崩溃
+		}
+		if crc32.ChecksumIEEE(payload) != sum {
+			return offset, nil
+		}
+		var rec walRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return offset, nil
+		}
+		w.apply(&rec)
+		w.records++
+		offset += int64(walHeaderSize) + int64(size)
+	}
+}
+
+// apply 将一条记录应用到有效任务（调用方持锁或在回放中）
+func (w *WAL) apply(rec *walRecord) {
+	switch rec.Op {
+	case walOpAdd:
+		if rec.Task == nil {
+			return
+		}
+		w.seq++
+		w.live[rec.TaskID] = &walEntry{seq: w.seq, task: rec.Task}
+	case walOpStart:
+		if e, ok := w.live[rec.TaskID]; ok {
+			e.inFlight = true
+		}
+	case walOpRetry:
+		e, ok := w.live[rec.TaskID]
+		if !ok {
+			if rec.Task == nil {
+				return
+			}
+			w.seq++
+			e = &walEntry{seq: w.seq, task: rec.Task}
+			w.live[rec.TaskID] = e
+		}
+		e.inFlight = false
+		e.retryCount = rec.RetryCount
+		e.class = rec.Class
+		e.lastError = rec.LastError
+		if rec.FailedAt != nil {
+			e.failedAt = *rec.FailedAt
+		}
+		if rec.Proof != nil {
+			e.proof = rec.Proof
+		}
+	case walOpDone:
+		delete(w.live, rec.TaskID)
+	}
+}
+
+// Recover 按入队顺序返回需要恢复的任务：排队中和正在计算的任务重新排队，等待重新提交的证明进入重试队列
+func (w *WAL) Recover() ([]*Task, []*RetryProof, WALRecovery) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	entries := make([]*walEntry, 0, len(w.live))
+	for _, e := range w.live {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+
+	var tasks []*Task
+	var retries []*RetryProof
+	var stats WALRecovery
+	for _, e := range entries {
+		switch {
+		case e.proof != nil:
+			retries = append(retries, &RetryProof{Task: e.task, Proof: e.proof, RetryCount: e.retryCount,
+				Class: e.class, LastError: e.lastError, FailedAt: e.failedAt})
+			stats.Retries++
+		case e.inFlight:
+			e.inFlight = false
+			tasks = append(tasks, e.task)
+			stats.InFlight++
+		default:
+			tasks = append(tasks, e.task)
+			stats.Queued++
+		}
+	}
+	return tasks, retries, stats
+}
+
+// Add 记录任务入队
+func (w *WAL) Add(task *Task) {
+	w.append(&walRecord{Op: walOpAdd, TaskID: task.TaskID, Task: task})
+}
+
+// Start 记录任务开始证明计算，崩溃后重新排队
+func (w *WAL) Start(taskID string) {
+	w.append(&walRecord{Op: walOpStart, TaskID: taskID})
+}
+
+// Retry 记录等待重新提交的证明；同一任务再次重试时只记录次数和错误，不重复写入证明
+func (w *WAL) Retry(rp *RetryProof) {
+	rec := &walRecord{Op: walOpRetry, TaskID: rp.Task.TaskID, RetryCount: rp.RetryCount,
+		Class: rp.Class, LastError: rp.LastError, FailedAt: timePtr(rp.FailedAt)}
+	w.mu.Lock()
+	e, ok := w.live[rp.Task.TaskID]
+	w.mu.Unlock()
+	if !ok || e.proof == nil {
+		rec.Task = rp.Task
+		rec.Proof = rp.Proof
+	}
+	w.append(rec)
+}
+
+// Done 记录任务结束，不再恢复
+func (w *WAL) Done(taskID string) {
+	w.append(&walRecord{Op: walOpDone, TaskID: taskID})
+}
+
+// append 写入一条记录并按刷盘策略刷盘（always 策略下刷盘成功），之后才应用到有效任务；记录数过多时压缩
+func (w *WAL) append(rec *walRecord) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return // 已关闭
+	}
+	data, err := encodeRecord(rec)
+	if err != nil {
+		w.fail(err)
+		return
+	}
+	if _, err := w.buf.Write(data); err != nil {
+		w.recover(err)
+		return
+	}
+	w.records++
+	w.pending += int64(len(data))
+	w.pendingRecords++
+	w.dirty = true
+	if w.opts.SyncInterval == 0 {
+		if err := w.flush(true); err != nil {
+			// 每条记录都刷盘，缓冲区中只有这一条且尚未应用，截断即可
+			w.records -= w.pendingRecords
+			w.pending, w.pendingRecords = 0, 0
+			w.recover(err)
+			return
+		}
+	}
+	w.apply(rec)
+	if w.opts.CompactRecords > 0 && w.records > w.opts.CompactRecords && w.records > 2*w.liveRecords() {
+		if err := w.compact(); err != nil {
+			w.recover(fmt.Errorf("压缩任务队列日志失败: %v", err))
+		}
+	}
+}
+
+// timePtr 零值时间返回 nil，不写入记录
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// encodeRecord 编码一条记录: 长度 + CRC32 + JSON
+func encodeRecord(rec *walRecord) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, walHeaderSize, walHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(data[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(data[4:8], crc32.ChecksumIEEE(payload))
+	return append(data, payload...), nil
+}
+
+// flush 写出缓冲区，sync 为 true 时刷盘（调用方持锁）；写出失败时由调用方 recover
+func (w *WAL) flush(sync bool) error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if sync {
+		if err := w.file.Sync(); err != nil {
+			return err
+		}
+	}
+	w.good += w.pending
+	w.pending, w.pendingRecords = 0, 0
+	w.dirty = false
+	return nil
+}
+
+// liveRecords 有效任务压缩后的记录数（调用方持锁）
+func (w *WAL) liveRecords() int {
+	n := 0
+	for _, e := range w.live {
+		n++
+		if e.inFlight {
+			n++
+		}
+	}
+	return n
+}
+
+// compact 只写出有效任务到临时文件，刷盘后替换原日志（调用方持锁）
+func (w *WAL) compact() error {
+	if err := w.flush(false); err != nil {
+		return err
+	}
+	entries := make([]*walEntry, 0, len(w.live))
+	for _, e := range w.live {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq < entries[j].seq })
+
+	tmpPath := w.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	out := bufio.NewWriter(tmp)
+	records := 0
+	var size int64
+	for _, e := range entries {
+		var recs []*walRecord
+		if e.proof != nil {
+			recs = append(recs, &walRecord{Op: walOpRetry, TaskID: e.task.TaskID, Task: e.task, Proof: e.proof, RetryCount: e.retryCount,
+				Class: e.class, LastError: e.lastError, FailedAt: timePtr(e.failedAt)})
+		} else {
+			recs = append(recs, &walRecord{Op: walOpAdd, TaskID: e.task.TaskID, Task: e.task})
+			if e.inFlight {
+				recs = append(recs, &walRecord{Op: walOpStart, TaskID: e.task.TaskID})
+			}
+		}
+		for _, rec := range recs {
+			data, err := encodeRecord(rec)
+			if err == nil {
+				_, err = out.Write(data)
+			}
+			if err != nil {
+				tmp.Close()
+				return err
+			}
+			records++
+			size += int64(len(data))
+		}
+	}
+	if err := out.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// 新文件需要重新加锁，先打开再替换
+	f, err := os.OpenFile(tmpPath, os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		return err
+	}
+	if err := os.Rename(tmpPath, w.path); err != nil {
+		f.Close()
+		return err
+	}
+	syncDir(w.opts.Dir)
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return err
+	}
+	w.file.Close()
+	w.file = f
+	w.buf = bufio.NewWriter(f)
+	w.records = records
+	w.good = size
+	w.pending, w.pendingRecords = 0, 0
+	return nil
+}
+
+// recover 写日志失败后恢复（调用方持锁）：bufio.Writer 出错后一直失败，需要重建；
+// 文件截断到最后一条完整记录，避免下次启动回放时在中间的残缺记录处停止、丢掉其后的有效记录。
+// 缓冲区中已应用但没有写入文件的记录随之丢失，此时按内存中的有效任务重写日志
+func (w *WAL) recover(err error) {
+	w.fail(err)
+	lost := w.pendingRecords
+	w.records -= lost
+	w.pending, w.pendingRecords = 0, 0
+	if err := w.file.Truncate(w.good); err != nil {
+		w.fail(fmt.Errorf("截断任务队列日志失败: %v", err))
+	}
+	if _, err := w.file.Seek(w.good, io.SeekStart); err != nil {
+		w.fail(fmt.Errorf("定位任务队列日志失败: %v", err))
+	}
+	w.buf = bufio.NewWriter(w.file)
+	if lost > 0 {
+		if err := w.compact(); err != nil {
+			w.fail(fmt.Errorf("重写任务队列日志失败: %v", err))
+		}
+	}
+}
+
+// syncLoop interval 策略下后台定时刷盘
+func (w *WAL) syncLoop() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			if w.file != nil && w.dirty {
+				if err := w.flush(true); err != nil {
+					w.recover(err)
+				}
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+// fail 报告写日志失败（调用方持锁）
+func (w *WAL) fail(err error) {
+	if w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+// Close 刷盘并关闭日志，之后的记录被忽略
+func (w *WAL) Close() error {
+	if w.stop != nil {
+		close(w.stop)
+		<-w.done
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.flush(true)
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	w.file = nil
+	return err
+}
+
+// syncDir 刷新目录项，保证重命名在掉电后仍然有效
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
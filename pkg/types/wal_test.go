@@ -0,0 +1,270 @@
+package types
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// openTestWAL 在临时目录打开日志，每条记录写入后刷盘
+func openTestWAL(t *testing.T, dir string, compact int) *WAL {
+	t.Helper()
+	w, err := OpenWAL(WALOptions{Dir: dir, CompactRecords: compact})
+	if err != nil {
+		t.Fatalf("打开日志失败: %v", err)
+	}
+	return w
+}
+
+func testTask(id string) *Task {
+	return &Task{TaskID: id, ProgramID: "fib_input", NodeID: "1001", PublicInputs: []byte(id)}
+}
+
+func taskIDs(tasks []*Task) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.TaskID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestWALRecover 测试重启后按入队顺序恢复排队中、正在计算和等待重新提交的任务
+func TestWALRecover(t *testing.T) {
+	dir := t.TempDir()
+	w := openTestWAL(t, dir, 0)
+	for _, id := range []string{"t1", "t2", "t3", "t4", "t5"} {
+		w.Add(testTask(id))
+	}
+	w.Start("t2") // 崩溃时正在计算
+	w.Start("t3") // 计算完成，等待重新提交
+	w.Retry(&RetryProof{Task: testTask("t3"), Proof: []byte("proof-3"), RetryCount: 1})
+	w.Retry(&RetryProof{Task: testTask("t3"), Proof: []byte("proof-3"), RetryCount: 2}) // 再次重试只记录次数
+	w.Start("t4")
+	w.Done("t4")
+	if err := w.Close(); err != nil {
+		t.Fatalf("关闭日志失败: %v", err)
+	}
+
+	w = openTestWAL(t, dir, 0)
+	defer w.Close()
+	tasks, retries, stats := w.Recover()
+	if want := []string{"t1", "t2", "t5"}; !equalIDs(taskIDs(tasks), want) {
+		t.Errorf("恢复的任务 = %v, 期望 %v", taskIDs(tasks), want)
+	}
+	if stats != (WALRecovery{Queued: 2, InFlight: 1, Retries: 1}) {
+		t.Errorf("恢复统计 = %+v", stats)
+	}
+	if len(retries) != 1 || retries[0].Task.TaskID != "t3" || string(retries[0].Proof) != "proof-3" || retries[0].RetryCount != 2 {
+		t.Fatalf("恢复的重试 = %+v", retries)
+	}
+	if string(tasks[0].PublicInputs) != "t1" {
+		t.Errorf("任务数据未恢复: %q", tasks[0].PublicInputs)
+	}
+}
+
+// TestWALTornRecord 测试末尾写了一半的记录被截断，之前的记录正常恢复，之后可以继续追加
+func TestWALTornRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		tail func(data []byte) []byte // 在完整日志后追加的内容
+	}{
+		{"头部不完整", func(data []byte) []byte { return data[:3] }},
+		{"内容不完整", func(data []byte) []byte { return data[:len(data)-2] }},
+		{"校验和错误", func(data []byte) []byte {
+			bad := append([]byte(nil), data...)
+			bad[len(bad)-1] ^= 0xff
+			return bad
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w := openTestWAL(t, dir, 0)
+			w.Add(testTask("t1"))
+			w.Add(testTask("t2"))
+			w.Close()
+
+			path := filepath.Join(dir, walFileName)
+			before, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := encodeRecord(&walRecord{Op: walOpAdd, TaskID: "t3", Task: testTask("t3")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Write(tt.tail(data))
+			f.Close()
+
+			w = openTestWAL(t, dir, 0)
+			tasks, _, _ := w.Recover()
+			if want := []string{"t1", "t2"}; !equalIDs(taskIDs(tasks), want) {
+				t.Errorf("恢复的任务 = %v, 期望 %v", taskIDs(tasks), want)
+			}
+			if after, _ := os.Stat(path); after.Size() != before.Size() {
+				t.Errorf("残缺记录未截断: %d => %d 字节", before.Size(), after.Size())
+			}
+			w.Add(testTask("t4"))
+			w.Close()
+
+			w = openTestWAL(t, dir, 0)
+			defer w.Close()
+			tasks, _, _ = w.Recover()
+			if want := []string{"t1", "t2", "t4"}; !equalIDs(taskIDs(tasks), want) {
+				t.Errorf("截断后追加的任务 = %v, 期望 %v", taskIDs(tasks), want)
+			}
+		})
+	}
+}
+
+// TestWALCompact 测试失效记录过多时压缩日志，压缩后的日志恢复结果不变
+func TestWALCompact(t *testing.T) {
+	dir := t.TempDir()
+	w := openTestWAL(t, dir, 10)
+	for i := 0; i < 20; i++ {
+		id := string(rune('a' + i))
+		w.Add(testTask(id))
+		if i%4 != 0 {
+			w.Start(id)
+			w.Done(id)
+		}
+	}
+	w.Start("a")
+	w.Retry(&RetryProof{Task: testTask("e"), Proof: []byte("proof-e"), RetryCount: 1})
+	if w.records > 2*w.liveRecords() || w.records > 20 {
+		t.Errorf("日志未压缩: %d 条记录, 有效 %d 条", w.records, w.liveRecords())
+	}
+	w.Close()
+
+	w = openTestWAL(t, dir, 10)
+	defer w.Close()
+	tasks, retries, stats := w.Recover()
+	if want := []string{"a", "i", "m", "q"}; !equalIDs(taskIDs(tasks), want) {
+		t.Errorf("恢复的任务 = %v, 期望 %v", taskIDs(tasks), want)
+	}
+	if stats.InFlight != 1 || len(retries) != 1 || string(retries[0].Proof) != "proof-e" {
+		t.Errorf("恢复统计 = %+v, 重试 = %v", stats, retries)
+	}
+}
+
+// partialWriter 只写入一半数据后返回错误，模拟磁盘写满
+type partialWriter struct {
+	f *os.File
+}
+
+func (p *partialWriter) Write(data []byte) (int, error) {
+	n, _ := p.f.Write(data[:len(data)/2])
+	return n, errors.New("no space left on device")
+}
+
+// TestWALWriteFailure 测试写入失败时截断残缺记录、不应用该记录，之后的记录仍能写入并恢复
+func TestWALWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	var errs []error
+	w, err := OpenWAL(WALOptions{Dir: dir, OnError: func(err error) { errs = append(errs, err) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Add(testTask("t1"))
+	w.buf = bufio.NewWriter(&partialWriter{f: w.file})
+	w.Add(testTask("t2"))
+	if len(errs) == 0 {
+		t.Fatal("写入失败未报告")
+	}
+	if _, ok := w.live["t2"]; ok {
+		t.Error("写入失败的记录不应生效")
+	}
+	w.Add(testTask("t3"))
+	w.Close()
+
+	w = openTestWAL(t, dir, 0)
+	defer w.Close()
+	tasks, _, _ := w.Recover()
+	if want := []string{"t1", "t3"}; !equalIDs(taskIDs(tasks), want) {
+		t.Errorf("恢复的任务 = %v, 期望 %v", taskIDs(tasks), want)
+	}
+}
+
+// TestWALRetryMetadata 测试重启（含压缩）后恢复的重试保留错误类型、最后错误和首次失败时间
+func TestWALRetryMetadata(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		dir := t.TempDir()
+		w := openTestWAL(t, dir, 0)
+		failedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		rp := &RetryProof{Task: testTask("t1"), Proof: []byte("proof-1"), RetryCount: 1, Class: "server", LastError: "500", FailedAt: failedAt}
+		w.Add(rp.Task)
+		w.Start("t1")
+		w.Retry(rp)
+		rp.RetryCount, rp.Class, rp.LastError = 2, "rate_limit", "429"
+		w.Retry(rp)
+		if compact {
+			w.mu.Lock()
+			if err := w.compact(); err != nil {
+				t.Fatal(err)
+			}
+			w.mu.Unlock()
+		}
+		w.Close()
+
+		w = openTestWAL(t, dir, 0)
+		_, retries, _ := w.Recover()
+		w.Close()
+		if len(retries) != 1 {
+			t.Fatalf("压缩=%v: 恢复的重试 = %v", compact, retries)
+		}
+		got := retries[0]
+		if got.RetryCount != 2 || got.Class != "rate_limit" || got.LastError != "429" || !got.FailedAt.Equal(failedAt) {
+			t.Errorf("压缩=%v: 恢复的重试 = %+v", compact, got)
+		}
+	}
+}
+
+// TestAddTaskCapacityConcurrent 测试写日志期间释放队列锁后，并发入队仍不超过容量
+func TestAddTaskCapacityConcurrent(t *testing.T) {
+	tq, _, err := OpenTaskQueue(5, 5, WALOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tq.Close()
+	var wg sync.WaitGroup
+	var added, full int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			switch err := tq.AddTask(testTask(fmt.Sprintf("t%d", i))); {
+			case err == nil:
+				atomic.AddInt64(&added, 1)
+			case errors.Is(err, ErrQueueFull):
+				atomic.AddInt64(&full, 1)
+			}
+			tq.Len() // 写日志期间不持锁，Len 不被阻塞
+		}(i)
+	}
+	wg.Wait()
+	if added != 5 || full != 15 || tq.Len() != 5 {
+		t.Errorf("入队 %d, 队列已满 %d, 队列长度 %d", added, full, tq.Len())
+	}
+}